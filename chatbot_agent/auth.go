@@ -0,0 +1,117 @@
+// chatbot_agent/auth.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb" // Ensure this path is correct
+)
+
+const (
+	// Chat command that replies with a link to connect the user's Google account
+	connectCommand = "/connect"
+	// Chat command, followed by the code shown after consenting, that
+	// finishes connecting the account
+	confirmCommand = "/confirm"
+	// Chat command that revokes access to the user's Google account
+	disconnectCommand = "/disconnect"
+)
+
+// connectAccountMessage asks the MCP server for an authorization link for
+// userID and returns the reply sent to the user.
+func connectAccountMessage(ctx context.Context, userID string) string {
+	rpcCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	resp, err := mcpAuthClient.StartAuthorization(rpcCtx, &pb.StartAuthorizationRequest{
+		Common: &pb.CommonRequest{UserId: userID},
+	})
+	if err != nil {
+		log.Printf("Error starting authorization for user %s: %v", userID, err)
+		return "Lo siento, no pude generar el enlace para conectar tu cuenta de Google. Intenta de nuevo más tarde."
+	}
+
+	validity := ""
+	if expires, err := time.Parse(time.RFC3339, resp.ExpiresAt); err == nil {
+		validity = fmt.Sprintf(" (válido durante %d minutos)", int(time.Until(expires).Round(time.Minute).Minutes()))
+	}
+	return fmt.Sprintf("Para conectar tu cuenta de Google, abre este enlace%s y acepta los permisos: %s\nAl terminar verás un código; envíamelo aquí como %s <código>.", validity, resp.AuthorizationUrl, confirmCommand)
+}
+
+// confirmAccountMessage confirms the authorization userID consented to with
+// code and returns the reply sent to the user, naming the connected account
+// so they can tell if it is not theirs.
+func confirmAccountMessage(ctx context.Context, userID, code string) string {
+	if code == "" {
+		return fmt.Sprintf("Envíame el código que viste al aceptar los permisos, por ejemplo: %s 123456", confirmCommand)
+	}
+
+	rpcCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	resp, err := mcpAuthClient.ConfirmAuthorization(rpcCtx, &pb.ConfirmAuthorizationRequest{
+		Common: &pb.CommonRequest{UserId: userID},
+		Code:   code,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.InvalidArgument:
+		return "El código no es correcto. Revisa el que aparece en la página al aceptar los permisos."
+	case codes.FailedPrecondition:
+		return fmt.Sprintf("No hay ninguna cuenta pendiente de confirmar, o el código venció. Escribe %s para obtener un enlace nuevo.", connectCommand)
+	default:
+		log.Printf("Error confirming authorization for user %s: %v", userID, err)
+		return "Lo siento, no pude confirmar la conexión de tu cuenta de Google. Intenta de nuevo más tarde."
+	}
+
+	if resp.Email == "" {
+		return "Listo, tu cuenta de Google quedó conectada."
+	}
+	return fmt.Sprintf("Listo, quedó conectada la cuenta de Google %s. Si no es la tuya, escribe %s para conectar otra.", resp.Email, connectCommand)
+}
+
+// disconnectAccountMessage asks the MCP server to revoke access to the
+// Google account of userID and returns the reply sent to the user.
+func disconnectAccountMessage(ctx context.Context, userID string) string {
+	rpcCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	resp, err := mcpAuthClient.RevokeAccess(rpcCtx, &pb.RevokeAccessRequest{
+		Common: &pb.CommonRequest{UserId: userID},
+	})
+	if err != nil {
+		log.Printf("Error revoking access for user %s: %v", userID, err)
+		return "Lo siento, no pude desconectar tu cuenta de Google. Tu cuenta sigue conectada; intenta de nuevo más tarde."
+	}
+	if !resp.WasConnected {
+		return "No tienes ninguna cuenta de Google conectada."
+	}
+	reply := "He desconectado tu cuenta de Google y revocado todos los permisos."
+	if resp.CancelledScheduledEmails > 0 {
+		reply += fmt.Sprintf(" También cancelé %d correo(s) programado(s) que ya no podrían enviarse.", resp.CancelledScheduledEmails)
+	}
+	return reply + fmt.Sprintf(" Usa %s si quieres volver a conectarla.", connectCommand)
+}
+
+// authorizationLink returns the link to grant access carried by an MCP call
+// that failed because the user has not connected their Google account or
+// has not granted access to the service the call uses.
+func authorizationLink(err error) string {
+	st, ok := status.FromError(err)
+	if !ok || (st.Code() != codes.Unauthenticated && st.Code() != codes.PermissionDenied) {
+		return ""
+	}
+	for _, d := range st.Details() {
+		if help, ok := d.(*errdetails.Help); ok && len(help.Links) > 0 {
+			return help.Links[0].Url
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+// chatbot_agent/contact_resolution.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb" // Ensure this path is correct
+)
+
+const (
+	// Minimum score for the best contact match to be used without asking the user
+	minResolvedMatchScore = 70
+	// How much the best match must beat the runner-up to be considered unambiguous
+	minResolvedMatchLead = 20
+	// Maximum number of candidates listed back to Gemini for an ambiguous name
+	maxListedCandidates = 5
+)
+
+// resolveEmailAddress turns a recipient given by the model into an email
+// address. Addresses are returned as they are; names are looked up with
+// SearchContacts. If no contact matches well, or more than one does, an
+// error listing the candidates is returned so Gemini asks the user.
+func resolveEmailAddress(ctx context.Context, commonReq *pb.CommonRequest, recipient string) (string, error) {
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" || strings.Contains(recipient, "@") {
+		return recipient, nil
+	}
+
+	resp, err := mcpContactsClient.SearchContacts(ctx, &pb.SearchContactsRequest{
+		Common:     commonReq,
+		Query:      recipient,
+		MaxResults: 10,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not look up contact '%s': %w", recipient, err)
+	}
+
+	var candidates []*pb.ContactMatch
+	for _, m := range resp.Matches {
+		if len(m.Emails) > 0 {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no contact with an email address matches '%s'; ask the user for the address", recipient)
+	}
+
+	best := candidates[0]
+	if best.Score >= minResolvedMatchScore &&
+		(len(candidates) == 1 || best.Score-candidates[1].Score >= minResolvedMatchLead) {
+		return best.Emails[0], nil
+	}
+	if len(candidates) == 1 {
+		return "", fmt.Errorf("the only contact resembling '%s' is %s <%s>, which is a weak match; ask the user to confirm it",
+			recipient, best.DisplayName, strings.Join(best.Emails, ", "))
+	}
+
+	var listed []string
+	for i, m := range candidates {
+		if i == maxListedCandidates {
+			break
+		}
+		listed = append(listed, fmt.Sprintf("%s <%s>", m.DisplayName, strings.Join(m.Emails, ", ")))
+	}
+	return "", fmt.Errorf("'%s' matches several contacts, ask the user which one they mean: %s", recipient, strings.Join(listed, "; "))
+}
+
+// resolveEmailAddresses resolves every recipient with resolveEmailAddress.
+func resolveEmailAddresses(ctx context.Context, commonReq *pb.CommonRequest, recipients []string) ([]string, error) {
+	var addresses []string
+	for _, r := range recipients {
+		addr, err := resolveEmailAddress(ctx, commonReq, r)
+		if err != nil {
+			return nil, err
+		}
+		if addr != "" {
+			addresses = append(addresses, addr)
+		}
+	}
+	return addresses, nil
+}
+
+// resolveRecipientList resolves a comma-separated list of recipients, as
+// accepted by the To header, into a comma-separated list of addresses.
+func resolveRecipientList(ctx context.Context, commonReq *pb.CommonRequest, to string) (string, error) {
+	addresses, err := resolveEmailAddresses(ctx, commonReq, strings.Split(to, ","))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(addresses, ", "), nil
+}
@@ -87,10 +87,144 @@ func InitGemini(ctx context.Context) error {
 								Type:        genai.TypeString,
 								Description: "Time zone of the event (e.g., 'America/Argentina/Buenos_Aires').",
 							},
+							"attendees": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "Guests to invite, as email addresses or contact names (names are looked up in the user's contacts).",
+							},
 						},
 						Required: []string{"calendar_id", "summary", "start_time", "end_time", "time_zone"},
 					},
 				},
+				{
+					Name:        "list_task_lists",
+					Description: "List the user's Google Tasks lists.",
+					Parameters: &genai.Schema{
+						Type:       genai.TypeObject,
+						Properties: map[string]*genai.Schema{},
+					},
+				},
+				{
+					Name:        "list_tasks",
+					Description: "List the user's pending to-dos from Google Tasks, optionally filtered by due date.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"task_list_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task list. Omit to use the default list.",
+							},
+							"show_completed": {
+								Type:        genai.TypeBoolean,
+								Description: "Also include completed tasks.",
+							},
+							"due_after": {
+								Type:        genai.TypeString,
+								Description: "Only tasks due on or after this date (YYYY-MM-DD).",
+							},
+							"due_before": {
+								Type:        genai.TypeString,
+								Description: "Only tasks due on or before this date (YYYY-MM-DD).",
+							},
+							"max_results": {
+								Type:        genai.TypeInteger,
+								Description: "Maximum number of tasks to return.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "create_task",
+					Description: "Add a to-do to the user's Google Tasks, e.g. for 'remind me to call the bank'. Tasks only have a due date, not a time; use a calendar event when the user needs a reminder at a specific time.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"title": {
+								Type:        genai.TypeString,
+								Description: "What has to be done (e.g., 'Call the bank').",
+							},
+							"notes": {
+								Type:        genai.TypeString,
+								Description: "Extra details about the task.",
+							},
+							"due": {
+								Type:        genai.TypeString,
+								Description: "Due date (YYYY-MM-DD).",
+							},
+							"task_list_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task list. Omit to use the default list.",
+							},
+						},
+						Required: []string{"title"},
+					},
+				},
+				{
+					Name:        "complete_task",
+					Description: "Mark a task as done. Get the task ID from list_tasks first.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"task_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task.",
+							},
+							"task_list_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task list. Omit for the default list.",
+							},
+						},
+						Required: []string{"task_id"},
+					},
+				},
+				{
+					Name:        "update_task",
+					Description: "Change the title, notes or due date of a task. Get the task ID from list_tasks first.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"task_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task.",
+							},
+							"task_list_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task list. Omit for the default list.",
+							},
+							"title": {
+								Type:        genai.TypeString,
+								Description: "New title. Omit to keep the current one.",
+							},
+							"notes": {
+								Type:        genai.TypeString,
+								Description: "New notes. Omit to keep the current ones.",
+							},
+							"due": {
+								Type:        genai.TypeString,
+								Description: "New due date (YYYY-MM-DD). Omit to keep the current one.",
+							},
+						},
+						Required: []string{"task_id"},
+					},
+				},
+				{
+					Name:        "delete_task",
+					Description: "Delete a task. Confirm with the user before calling it; to mark a task as done use complete_task instead.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"task_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task.",
+							},
+							"task_list_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the task list. Omit for the default list.",
+							},
+						},
+						Required: []string{"task_id"},
+					},
+				},
 				{
 					Name:        "send_email",
 					Description: "Send an email on behalf of the user.",
@@ -99,7 +233,7 @@ func InitGemini(ctx context.Context) error {
 						Properties: map[string]*genai.Schema{
 							"to": {
 								Type:        genai.TypeString,
-								Description: "Recipient's email address.",
+								Description: "Recipient's email address or the name of one of the user's contacts. Separate several recipients with commas.",
 							},
 							"subject": {
 								Type:        genai.TypeString,
@@ -113,9 +247,232 @@ func InitGemini(ctx context.Context) error {
 						Required: []string{"to", "subject", "body"},
 					},
 				},
+				{
+					Name:        "schedule_email",
+					Description: "Schedule an email to be sent on behalf of the user at a later time.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"to": {
+								Type:        genai.TypeString,
+								Description: "Recipient's email address or the name of one of the user's contacts. Separate several recipients with commas.",
+							},
+							"subject": {
+								Type:        genai.TypeString,
+								Description: "Subject of the email.",
+							},
+							"body": {
+								Type:        genai.TypeString,
+								Description: "Body content of the email.",
+							},
+							"send_at": {
+								Type:        genai.TypeString,
+								Description: "When to send the email, in RFC3339 format with the user's offset (e.g., '2025-05-23T08:00:00-03:00').",
+							},
+						},
+						Required: []string{"to", "subject", "body", "send_at"},
+					},
+				},
+				{
+					Name:        "list_scheduled_emails",
+					Description: "List the emails the user has scheduled to be sent later.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"include_finished": {
+								Type:        genai.TypeBoolean,
+								Description: "Also include emails that were already sent, failed or cancelled.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "cancel_scheduled_email",
+					Description: "Cancel an email scheduled to be sent later, before it is sent.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"id": {
+								Type:        genai.TypeString,
+								Description: "ID of the scheduled email, as returned by schedule_email or list_scheduled_emails.",
+							},
+						},
+						Required: []string{"id"},
+					},
+				},
+				{
+					Name:        "get_vacation_responder",
+					Description: "Get the user's Gmail vacation auto-reply (out-of-office) settings.",
+					Parameters: &genai.Schema{
+						Type:       genai.TypeObject,
+						Properties: map[string]*genai.Schema{},
+					},
+				},
+				{
+					Name:        "set_vacation_responder",
+					Description: "Turn the user's Gmail vacation auto-reply (out-of-office) on or off.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"enable": {
+								Type:        genai.TypeBoolean,
+								Description: "Whether the auto-reply should be on.",
+							},
+							"subject": {
+								Type:        genai.TypeString,
+								Description: "Subject of the auto-reply.",
+							},
+							"body": {
+								Type:        genai.TypeString,
+								Description: "Plain text body of the auto-reply.",
+							},
+							"start_time": {
+								Type:        genai.TypeString,
+								Description: "When the auto-reply starts, in RFC3339 format. Omit to start now.",
+							},
+							"end_time": {
+								Type:        genai.TypeString,
+								Description: "When the auto-reply ends, in RFC3339 format (e.g., next Monday at 00:00 in the user's time zone). Omit to keep it on until turned off.",
+							},
+							"restrict_to_contacts": {
+								Type:        genai.TypeBoolean,
+								Description: "Only reply to senders in the user's contacts.",
+							},
+						},
+						Required: []string{"enable"},
+					},
+				},
+				{
+					Name:        "list_gmail_filters",
+					Description: "List the filters configured in the user's Gmail account.",
+					Parameters: &genai.Schema{
+						Type:       genai.TypeObject,
+						Properties: map[string]*genai.Schema{},
+					},
+				},
+				{
+					Name:        "create_gmail_filter",
+					Description: "Create a Gmail filter that automatically labels, archives or marks as read incoming emails matching some criteria.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"from": {
+								Type:        genai.TypeString,
+								Description: "Match emails from this sender (e.g., 'noreply@example.com').",
+							},
+							"to": {
+								Type:        genai.TypeString,
+								Description: "Match emails sent to this recipient.",
+							},
+							"subject": {
+								Type:        genai.TypeString,
+								Description: "Match emails whose subject contains this text.",
+							},
+							"query": {
+								Type:        genai.TypeString,
+								Description: "Match emails using Gmail search syntax (e.g., 'has:attachment larger:5M').",
+							},
+							"add_labels": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "Names of labels to apply. Missing labels are created.",
+							},
+							"archive": {
+								Type:        genai.TypeBoolean,
+								Description: "Skip the inbox (archive matching emails).",
+							},
+							"mark_as_read": {
+								Type:        genai.TypeBoolean,
+								Description: "Mark matching emails as read.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "delete_gmail_filter",
+					Description: "Delete a Gmail filter.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"filter_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the filter, as returned by list_gmail_filters.",
+							},
+						},
+						Required: []string{"filter_id"},
+					},
+				},
+				{
+					Name:        "batch_modify_emails",
+					Description: "Apply an action to every email matching a Gmail search query: add or remove labels, mark as read or move to the trash. Always run it first with dry_run set to true, tell the user how many emails match and ask for confirmation before running it for real.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"query": {
+								Type:        genai.TypeString,
+								Description: "Gmail search query selecting the emails (e.g., 'category:promotions older_than:1m').",
+							},
+							"add_labels": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "Names of labels to apply. Missing labels are created.",
+							},
+							"remove_labels": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "Names of labels to remove (use 'INBOX' to archive).",
+							},
+							"mark_as_read": {
+								Type:        genai.TypeBoolean,
+								Description: "Mark the emails as read.",
+							},
+							"trash": {
+								Type:        genai.TypeBoolean,
+								Description: "Move the emails to the trash.",
+							},
+							"dry_run": {
+								Type:        genai.TypeBoolean,
+								Description: "Only count the matching emails without changing anything.",
+							},
+						},
+						Required: []string{"query", "dry_run"},
+					},
+				},
+				{
+					Name:        "unsubscribe_from_sender",
+					Description: "Unsubscribe the user from the mailing list that sent a given email, and optionally archive future emails from that sender.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"message_id": {
+								Type:        genai.TypeString,
+								Description: "ID of an email from the mailing list.",
+							},
+							"create_archive_filter": {
+								Type:        genai.TypeBoolean,
+								Description: "Also create a filter that archives future emails from the sender.",
+							},
+						},
+						Required: []string{"message_id"},
+					},
+				},
+				{
+					Name:        "search_contacts",
+					Description: "Search the user's contacts and the people they have emailed by name, email address or phone number. Use it to find someone's email address or phone number instead of guessing.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"query": {
+								Type:        genai.TypeString,
+								Description: "Name, email address or phone number to look for (e.g., 'Juan').",
+							},
+						},
+						Required: []string{"query"},
+					},
+				},
 				{
 					Name:        "list_contacts",
-					Description: "List connections (contacts) from the user's Google Contacts.",
+					Description: "List connections (contacts) from the user's Google Contacts, with their emails, phone numbers, organization, birthday, addresses and notes.",
 					Parameters: &genai.Schema{
 						Type: genai.TypeObject,
 						Properties: map[string]*genai.Schema{
@@ -123,13 +480,17 @@ func InitGemini(ctx context.Context) error {
 								Type:        genai.TypeInteger,
 								Description: "Maximum number of contacts to return per page.",
 							},
+							"group": {
+								Type:        genai.TypeString,
+								Description: "Only list the members of this contact group (label), by name or ID (e.g., 'Clients').",
+							},
 						},
 						Required: []string{"page_size"},
 					},
 				},
 				{
 					Name:        "create_contact",
-					Description: "Create a new contact in the user's Google Contacts.",
+					Description: "Create a new contact in the user's Google Contacts. If a similar contact already exists the call fails listing it; ask the user whether to update that contact instead, and only retry with allow_duplicate if they confirm it is a different person.",
 					Parameters: &genai.Schema{
 						Type: genai.TypeObject,
 						Properties: map[string]*genai.Schema{
@@ -143,12 +504,462 @@ func InitGemini(ctx context.Context) error {
 							},
 							"phone_number": {
 								Type:        genai.TypeString,
-								Description: "Phone number of the new contact.",
+								Description: "Phone number of the new contact, with its country code if the user gave one. Numbers without it are assumed to be from the user's country.",
+							},
+							"email_type": {
+								Type:        genai.TypeString,
+								Description: "Kind of email address: 'home', 'work' or 'other'.",
+							},
+							"phone_type": {
+								Type:        genai.TypeString,
+								Description: "Kind of phone number: 'mobile', 'home', 'work' or 'other'.",
+							},
+							"company": {
+								Type:        genai.TypeString,
+								Description: "Company or organization the contact works for.",
+							},
+							"job_title": {
+								Type:        genai.TypeString,
+								Description: "Job title of the contact.",
+							},
+							"birthday": {
+								Type:        genai.TypeString,
+								Description: "Birthday as 'YYYY-MM-DD', or '--MM-DD' if the year is unknown.",
+							},
+							"address": {
+								Type:        genai.TypeString,
+								Description: "Postal address of the contact, as a single line.",
+							},
+							"notes": {
+								Type:        genai.TypeString,
+								Description: "Free-form notes about the contact.",
+							},
+							"allow_duplicate": {
+								Type:        genai.TypeBoolean,
+								Description: "Create the contact even if a similar one exists. Only set it after the user confirmed.",
 							},
 						},
 						Required: []string{"display_name"}, // Email or phone can be optional
 					},
 				},
+				{
+					Name:        "update_contact",
+					Description: "Correct the name, email address or phone number of an existing contact. Get the contact ID and etag from search_contacts or list_contacts first; if the contact changed in the meantime, look it up again and retry.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"contact_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the contact (e.g., 'people/c123').",
+							},
+							"etag": {
+								Type:        genai.TypeString,
+								Description: "Etag of the contact, as returned with its ID.",
+							},
+							"display_name": {
+								Type:        genai.TypeString,
+								Description: "New display name. Omit to keep the current one.",
+							},
+							"email": {
+								Type:        genai.TypeString,
+								Description: "New email address. Omit to keep the current one.",
+							},
+							"phone_number": {
+								Type:        genai.TypeString,
+								Description: "New phone number. Omit to keep the current one.",
+							},
+						},
+						Required: []string{"contact_id", "etag"},
+					},
+				},
+				{
+					Name:        "list_contact_groups",
+					Description: "List the user's contact groups (labels in Google Contacts) with their member counts.",
+					Parameters: &genai.Schema{
+						Type:       genai.TypeObject,
+						Properties: map[string]*genai.Schema{},
+					},
+				},
+				{
+					Name:        "create_contact_group",
+					Description: "Create a new contact group (label) in the user's Google Contacts.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"name": {
+								Type:        genai.TypeString,
+								Description: "Name of the new group.",
+							},
+						},
+						Required: []string{"name"},
+					},
+				},
+				{
+					Name:        "add_contacts_to_group",
+					Description: "Add contacts to a contact group (label).",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"group": {
+								Type:        genai.TypeString,
+								Description: "Name or ID of the group (e.g., 'Clients').",
+							},
+							"contact_ids": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "IDs of the contacts to add (e.g., 'people/c123').",
+							},
+						},
+						Required: []string{"group", "contact_ids"},
+					},
+				},
+				{
+					Name:        "remove_contacts_from_group",
+					Description: "Remove contacts from a contact group (label). The contacts themselves are not deleted.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"group": {
+								Type:        genai.TypeString,
+								Description: "Name or ID of the group (e.g., 'Clients').",
+							},
+							"contact_ids": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "IDs of the contacts to remove (e.g., 'people/c123').",
+							},
+						},
+						Required: []string{"group", "contact_ids"},
+					},
+				},
+				{
+					Name:        "find_duplicate_contacts",
+					Description: "Find groups of contacts that look like the same person (same email, same phone number or a very similar name).",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"max_groups": {
+								Type:        genai.TypeInteger,
+								Description: "Maximum number of duplicate groups to return.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "merge_contacts",
+					Description: "Merge duplicate contacts into one. The first contact is kept and receives the emails, phone numbers and other details of the rest, which are deleted. Confirm with the user before calling it.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"contact_ids": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "IDs of the contacts to merge, the one to keep first (e.g., ['people/c123', 'people/c456']).",
+							},
+						},
+						Required: []string{"contact_ids"},
+					},
+				},
+				{
+					Name:        "import_vcard",
+					Description: "Import contacts from vCard (.vcf) text, e.g. a contact card the user shared. Creates new contacts, or merges into existing ones with the same email when update_existing is true.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"vcard_data": {
+								Type:        genai.TypeString,
+								Description: "The full vCard text, from BEGIN:VCARD to END:VCARD. Several cards can be concatenated.",
+							},
+							"update_existing": {
+								Type:        genai.TypeBoolean,
+								Description: "Merge cards into existing contacts that share an email address instead of creating duplicates.",
+							},
+						},
+						Required: []string{"vcard_data"},
+					},
+				},
+				{
+					Name:        "export_vcard",
+					Description: "Export contacts as vCard (.vcf) text, either specific contacts or a whole contact group.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"contact_ids": {
+								Type:        genai.TypeArray,
+								Items:       &genai.Schema{Type: genai.TypeString},
+								Description: "IDs of the contacts to export (e.g., 'people/c123').",
+							},
+							"group": {
+								Type:        genai.TypeString,
+								Description: "Name or ID of a contact group to export (e.g., 'Clients').",
+							},
+							"version": {
+								Type:        genai.TypeString,
+								Description: "vCard version, '3.0' (default) or '4.0'.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "delete_contact",
+					Description: "Delete a contact from the user's Google Contacts. Confirm with the user before calling it.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"contact_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the contact (e.g., 'people/c123').",
+							},
+							"etag": {
+								Type:        genai.TypeString,
+								Description: "Etag of the contact, as returned with its ID.",
+							},
+						},
+						Required: []string{"contact_id", "etag"},
+					},
+				},
+				{
+					Name:        "search_drive_files",
+					Description: "Search the user's Google Drive files, e.g. to find 'the Q3 budget spreadsheet'. Returns names, IDs and web links, most recently modified first.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"name_contains": {
+								Type:        genai.TypeString,
+								Description: "Text the file name contains (e.g., 'Q3 budget').",
+							},
+							"query": {
+								Type:        genai.TypeString,
+								Description: "Optional Drive query syntax filter, e.g. \"mimeType = 'application/vnd.google-apps.spreadsheet'\" or \"fullText contains 'invoice'\".",
+							},
+							"max_results": {
+								Type:        genai.TypeInteger,
+								Description: "Maximum number of files to return.",
+							},
+						},
+					},
+				},
+				{
+					Name:        "get_drive_file",
+					Description: "Get the details of a Drive file: name, type, size, owners, sharing and web link.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"file_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the file, from search_drive_files.",
+							},
+						},
+						Required: []string{"file_id"},
+					},
+				},
+				{
+					Name:        "read_drive_file",
+					Description: "Read the text of a Drive file (Google Docs and Slides as text, Sheets as CSV of the first sheet, and plain text files), e.g. to summarize it. For other files, give the user the web link instead.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"file_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the file, from search_drive_files.",
+							},
+						},
+						Required: []string{"file_id"},
+					},
+				},
+				{
+					Name:        "save_text_to_drive",
+					Description: "Save text as a new file in the user's Google Drive, e.g. notes or a draft the user asked to keep.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"name": {
+								Type:        genai.TypeString,
+								Description: "File name (e.g., 'Meeting notes.txt').",
+							},
+							"content": {
+								Type:        genai.TypeString,
+								Description: "Text to save.",
+							},
+							"as_google_doc": {
+								Type:        genai.TypeBoolean,
+								Description: "Convert the text into a Google Doc instead of a plain text file.",
+							},
+							"folder_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the folder to save it in. Omit for the root of My Drive.",
+							},
+						},
+						Required: []string{"name", "content"},
+					},
+				},
+				{
+					Name:        "share_drive_file",
+					Description: "Share a Drive file with someone, e.g. 'share the contract with legal@example.com'. Confirm the file and the person with the user first.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"file_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the file, from search_drive_files.",
+							},
+							"email": {
+								Type:        genai.TypeString,
+								Description: "Email address or contact name of the person to share it with.",
+							},
+							"role": {
+								Type:        genai.TypeString,
+								Description: "Access to grant: 'reader' (default), 'commenter' or 'writer'.",
+							},
+							"notify": {
+								Type:        genai.TypeBoolean,
+								Description: "Send the person an email about the shared file.",
+							},
+							"message": {
+								Type:        genai.TypeString,
+								Description: "Message included in the notification email.",
+							},
+						},
+						Required: []string{"file_id", "email"},
+					},
+				},
+				{
+					Name:        "create_document",
+					Description: "Create a new Google Doc, e.g. for meeting notes the user dictates.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"title": {
+								Type:        genai.TypeString,
+								Description: "Title of the document.",
+							},
+							"text": {
+								Type:        genai.TypeString,
+								Description: "Initial text of the document.",
+							},
+						},
+						Required: []string{"title"},
+					},
+				},
+				{
+					Name:        "append_to_document",
+					Description: "Add text as a new paragraph at the end of a Google Doc. Find the document ID with search_drive_files or create_document.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"document_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the document.",
+							},
+							"text": {
+								Type:        genai.TypeString,
+								Description: "Text to add.",
+							},
+						},
+						Required: []string{"document_id", "text"},
+					},
+				},
+				{
+					Name:        "read_document",
+					Description: "Read the text of a Google Doc.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"document_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the document.",
+							},
+						},
+						Required: []string{"document_id"},
+					},
+				},
+				{
+					Name:        "read_sheet_range",
+					Description: "Read cells from a Google Sheet. Read the header row first to learn the columns before writing to a sheet.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"spreadsheet_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the spreadsheet, from search_drive_files.",
+							},
+							"range": {
+								Type:        genai.TypeString,
+								Description: "Range in A1 notation (e.g., 'Expenses!A1:D20', or 'Expenses' for the whole sheet).",
+							},
+						},
+						Required: []string{"spreadsheet_id", "range"},
+					},
+				},
+				{
+					Name:        "append_sheet_rows",
+					Description: "Add rows at the end of a table in a Google Sheet, e.g. to log an expense. Values are interpreted as if typed by the user, so numbers and dates are recognized.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"spreadsheet_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the spreadsheet, from search_drive_files.",
+							},
+							"range": {
+								Type:        genai.TypeString,
+								Description: "The table to append to (e.g., 'Expenses!A:D').",
+							},
+							"rows": {
+								Type: genai.TypeArray,
+								Items: &genai.Schema{
+									Type:  genai.TypeArray,
+									Items: &genai.Schema{Type: genai.TypeString},
+								},
+								Description: "Rows to add, each a list of cell values in column order (e.g., [['2025-05-22', 'Taxi', '12.50']]).",
+							},
+						},
+						Required: []string{"spreadsheet_id", "range", "rows"},
+					},
+				},
+				{
+					Name:        "update_sheet_range",
+					Description: "Overwrite cells of a Google Sheet. Confirm with the user before overwriting existing values.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"spreadsheet_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the spreadsheet, from search_drive_files.",
+							},
+							"range": {
+								Type:        genai.TypeString,
+								Description: "Range to write, in A1 notation (e.g., 'Expenses!C5').",
+							},
+							"rows": {
+								Type: genai.TypeArray,
+								Items: &genai.Schema{
+									Type:  genai.TypeArray,
+									Items: &genai.Schema{Type: genai.TypeString},
+								},
+								Description: "New values, one list of cell values per row.",
+							},
+						},
+						Required: []string{"spreadsheet_id", "range", "rows"},
+					},
+				},
+				{
+					Name:        "prepare_meeting_briefing",
+					Description: "Prepare a briefing for a meeting: who the attendees are, their contact details, recent email threads with each of them and the documents attached to the event. Use this instead of chaining calendar, contact and email searches when the user asks to prepare for a meeting.",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"calendar_id": {
+								Type:        genai.TypeString,
+								Description: "The ID of the calendar holding the event. Defaults to 'primary'.",
+							},
+							"event_id": {
+								Type:        genai.TypeString,
+								Description: "ID of the event, from list_calendar_events. Leave empty for the next upcoming meeting with other attendees.",
+							},
+						},
+					},
+				},
 			},
 		},
 	}
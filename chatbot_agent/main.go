@@ -4,8 +4,10 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -115,24 +117,25 @@ func main() {
 func processMessage(ctx context.Context, userID, text string, nc *nats.Conn) {
 	log.Printf("Processing message for user %s: '%s'", userID, text)
 
+	switch strings.TrimSpace(text) {
+	case connectCommand:
+		SendResponse(nc, userID, connectAccountMessage(ctx, userID))
+		return
+	case disconnectCommand:
+		SendResponse(nc, userID, disconnectAccountMessage(ctx, userID))
+		return
+	}
+	if command, code, _ := strings.Cut(strings.TrimSpace(text), " "); command == confirmCommand {
+		SendResponse(nc, userID, confirmAccountMessage(ctx, userID, strings.TrimSpace(code)))
+		return
+	}
+
 	session, _ := GetOrCreateUserSession(userID)
 
 	if session.ChatSession == nil {
 		session.ChatSession = GetGeminiClient().StartChat()
 	}
 
-	if session.OAuthTokens == nil {
-		log.Printf("Attempting to load initial OAuth tokens for user %s...", userID)
-		_, loadedPBTokens, err := LoadAndPrepareTokens()
-		if err != nil {
-			log.Printf("Error loading initial tokens for user %s: %v. Please ensure token.json exists.", userID, err)
-			SendResponse(nc, userID, "Lo siento, necesito que autorices tu cuenta de Google. Puedes hacerlo siguiendo las instrucciones del servidor MCP. (Error: "+err.Error()+")")
-			return
-		}
-		UpdateUserSessionTokens(userID, loadedPBTokens)
-		log.Printf("Successfully loaded initial OAuth tokens for user %s.", userID)
-	}
-
 	geminiChatSession := session.ChatSession
 	geminiChatSession.History = append(geminiChatSession.History, &genai.Content{
 		Parts: []genai.Part{genai.Text(text)},
@@ -149,6 +152,7 @@ func processMessage(ctx context.Context, userID, text string, nc *nats.Conn) {
 	// --- Inicio de la lógica para manejar múltiples tool calls ---
 	var toolResponses []genai.Part // Usaremos esto para recolectar todas las respuestas de las herramientas
 	var hasToolCalls bool = false
+	authLinkSent := false
 
 	for _, part := range resp.Candidates[0].Content.Parts {
 		if tc, ok := part.(genai.FunctionCall); ok {
@@ -170,9 +174,19 @@ func processMessage(ctx context.Context, userID, text string, nc *nats.Conn) {
 				}
 			}
 
-			toolOutput, toolErr := ExecuteToolCall(ctx, userID, session.OAuthTokens, tc.Name, tc.Args)
+			toolOutput, toolErr := ExecuteToolCall(ctx, userID, tc.Name, tc.Args)
 			if toolErr != nil {
 				log.Printf("Error executing tool '%s' for user %s: %v", tc.Name, userID, toolErr)
+				// Send authorization links to the user ourselves, as Gemini may not repeat them.
+				if link := authorizationLink(toolErr); link != "" {
+					if !authLinkSent {
+						SendResponse(nc, userID, "Para hacer esto necesito acceso a tu cuenta de Google. Autorízalo en este enlace y vuelve a pedírmelo: "+link)
+						authLinkSent = true
+					}
+					toolErr = fmt.Errorf("%w (the user was already sent the authorization link; ask them to try again once they have granted access)", toolErr)
+				} else {
+					toolErr = explainToolError(toolErr)
+				}
 				// Si hay un error, lo enviamos como una FunctionResponse con el error
 				toolResponses = append(toolResponses, genai.FunctionResponse{
 					Name: tc.Name,
@@ -3,13 +3,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"strings"
 	"time"
 
-	"golang.org/x/oauth2" // For loading token.json
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
@@ -18,13 +16,19 @@ import (
 
 const (
 	mcpServerAddress = "localhost:50051" // Address of your MCP gRPC server
-	tokenCacheFile   = "token.json"      // Location of the token.json for the chatbot
+	// Maximum number of characters of a Drive file passed back to Gemini
+	maxDriveTextChars = 20000
 )
 
 var (
 	mcpCalendarClient pb.CalendarServiceClient
+	mcpTasksClient    pb.TasksServiceClient
 	mcpGmailClient    pb.GmailServiceClient
 	mcpContactsClient pb.ContactsServiceClient
+	mcpDriveClient    pb.DriveServiceClient
+	mcpDocsClient     pb.DocsServiceClient
+	mcpSheetsClient   pb.SheetsServiceClient
+	mcpAuthClient     pb.AuthServiceClient
 )
 
 // InitMCPClients initializes gRPC clients for the MCP services.
@@ -37,15 +41,21 @@ func InitMCPClients(ctx context.Context) error {
 	// for the duration of the chatbot server's life. Close it in main cleanup if needed.
 
 	mcpCalendarClient = pb.NewCalendarServiceClient(conn)
+	mcpTasksClient = pb.NewTasksServiceClient(conn)
 	mcpGmailClient = pb.NewGmailServiceClient(conn)
 	mcpContactsClient = pb.NewContactsServiceClient(conn)
+	mcpDriveClient = pb.NewDriveServiceClient(conn)
+	mcpDocsClient = pb.NewDocsServiceClient(conn)
+	mcpSheetsClient = pb.NewSheetsServiceClient(conn)
+	mcpAuthClient = pb.NewAuthServiceClient(conn)
 	log.Println("MCP gRPC clients initialized.")
 	return nil
 }
 
 // ExecuteToolCall dispatches the tool call to the appropriate MCP client.
-func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens, toolName string, args map[string]interface{}) (interface{}, error) {
-	commonReq := &pb.CommonRequest{AuthTokens: tokens}
+func ExecuteToolCall(ctx context.Context, userID, toolName string, args map[string]interface{}) (interface{}, error) {
+	// The MCP server keeps the Google tokens of each user.
+	commonReq := &pb.CommonRequest{UserId: userID, DefaultPhoneRegion: whatsAppPhoneRegion(userID)}
 
 	rpcCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
@@ -86,6 +96,10 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		startTime, _ := args["start_time"].(string)
 		endTime, _ := args["end_time"].(string)
 		timeZone, _ := args["time_zone"].(string)
+		attendees, err := resolveEmailAddresses(rpcCtx, commonReq, stringSliceArg(args, "attendees"))
+		if err != nil {
+			return nil, err
+		}
 
 		req := &pb.CreateEventRequest{
 			Common:      commonReq,
@@ -95,6 +109,7 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 			StartTime:   startTime,
 			EndTime:     endTime,
 			TimeZone:    timeZone,
+			Attendees:   attendees,
 		}
 		resp, err := mcpCalendarClient.CreateEvent(rpcCtx, req)
 		if err != nil {
@@ -105,10 +120,145 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		}
 		return map[string]interface{}{"event_id": resp.CreatedEvent.Id, "summary": resp.CreatedEvent.Summary, "link": resp.CreatedEvent.HtmlLink}, nil
 
+	case "list_task_lists":
+		req := &pb.ListTaskListsRequest{Common: commonReq}
+		resp, err := mcpTasksClient.ListTaskLists(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("list_task_lists RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("list_task_lists MCP error: %s", resp.Common.Message)
+		}
+		var lists []map[string]interface{}
+		for _, l := range resp.TaskLists {
+			lists = append(lists, map[string]interface{}{"id": l.Id, "title": l.Title})
+		}
+		return map[string]interface{}{"task_lists": lists}, nil
+
+	case "list_tasks":
+		taskListID, _ := args["task_list_id"].(string)
+		showCompleted, _ := args["show_completed"].(bool)
+		dueAfter, _ := args["due_after"].(string)
+		dueBefore, _ := args["due_before"].(string)
+		maxResults, _ := args["max_results"].(float64)
+
+		req := &pb.ListTasksRequest{
+			Common:        commonReq,
+			TaskListId:    taskListID,
+			ShowCompleted: showCompleted,
+			DueAfter:      dueAfter,
+			DueBefore:     dueBefore,
+			MaxResults:    int32(maxResults),
+		}
+		resp, err := mcpTasksClient.ListTasks(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("list_tasks RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("list_tasks MCP error: %s", resp.Common.Message)
+		}
+		var taskList []map[string]interface{}
+		for _, t := range resp.Tasks {
+			taskList = append(taskList, map[string]interface{}{
+				"id":     t.Id,
+				"title":  t.Title,
+				"notes":  t.Notes,
+				"due":    t.Due,
+				"status": t.Status,
+			})
+		}
+		return map[string]interface{}{"tasks": taskList}, nil
+
+	case "create_task":
+		title, _ := args["title"].(string)
+		notes, _ := args["notes"].(string)
+		due, _ := args["due"].(string)
+		taskListID, _ := args["task_list_id"].(string)
+
+		req := &pb.CreateTaskRequest{
+			Common:     commonReq,
+			TaskListId: taskListID,
+			Title:      title,
+			Notes:      notes,
+			Due:        due,
+		}
+		resp, err := mcpTasksClient.CreateTask(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("create_task RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("create_task MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"task_id": resp.CreatedTask.Id, "title": resp.CreatedTask.Title, "due": resp.CreatedTask.Due}, nil
+
+	case "complete_task":
+		taskID, _ := args["task_id"].(string)
+		taskListID, _ := args["task_list_id"].(string)
+
+		req := &pb.CompleteTaskRequest{
+			Common:     commonReq,
+			TaskListId: taskListID,
+			TaskId:     taskID,
+		}
+		resp, err := mcpTasksClient.CompleteTask(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("complete_task RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("complete_task MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"task_id": resp.Task.Id, "title": resp.Task.Title, "status": resp.Task.Status}, nil
+
+	case "update_task":
+		taskID, _ := args["task_id"].(string)
+		taskListID, _ := args["task_list_id"].(string)
+		title, _ := args["title"].(string)
+		notes, _ := args["notes"].(string)
+		due, _ := args["due"].(string)
+
+		req := &pb.UpdateTaskRequest{
+			Common:     commonReq,
+			TaskListId: taskListID,
+			TaskId:     taskID,
+			Title:      title,
+			Notes:      notes,
+			Due:        due,
+		}
+		resp, err := mcpTasksClient.UpdateTask(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("update_task RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("update_task MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"task_id": resp.UpdatedTask.Id, "title": resp.UpdatedTask.Title, "notes": resp.UpdatedTask.Notes, "due": resp.UpdatedTask.Due}, nil
+
+	case "delete_task":
+		taskID, _ := args["task_id"].(string)
+		taskListID, _ := args["task_list_id"].(string)
+
+		req := &pb.DeleteTaskRequest{
+			Common:     commonReq,
+			TaskListId: taskListID,
+			TaskId:     taskID,
+		}
+		resp, err := mcpTasksClient.DeleteTask(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("delete_task RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("delete_task MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"message": resp.Common.Message}, nil
+
 	case "send_email":
 		to, _ := args["to"].(string)
 		subject, _ := args["subject"].(string)
 		body, _ := args["body"].(string)
+		to, err := resolveRecipientList(rpcCtx, commonReq, to)
+		if err != nil {
+			return nil, err
+		}
 
 		req := &pb.SendEmailRequest{
 			Common:  commonReq,
@@ -125,14 +275,244 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		}
 		return map[string]interface{}{"message_id": resp.MessageId}, nil
 
+	case "schedule_email":
+		to, _ := args["to"].(string)
+		subject, _ := args["subject"].(string)
+		body, _ := args["body"].(string)
+		sendAt, _ := args["send_at"].(string)
+		to, err := resolveRecipientList(rpcCtx, commonReq, to)
+		if err != nil {
+			return nil, err
+		}
+
+		req := &pb.ScheduleEmailRequest{
+			Common:  commonReq,
+			To:      to,
+			Subject: subject,
+			Body:    body,
+			SendAt:  sendAt,
+		}
+		resp, err := mcpGmailClient.ScheduleEmail(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("schedule_email RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("schedule_email MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"scheduled_email_id": resp.ScheduledEmail.Id, "send_at": resp.ScheduledEmail.SendAt}, nil
+
+	case "list_scheduled_emails":
+		includeFinished, _ := args["include_finished"].(bool)
+
+		req := &pb.ListScheduledRequest{
+			Common:          commonReq,
+			IncludeFinished: includeFinished,
+		}
+		resp, err := mcpGmailClient.ListScheduled(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("list_scheduled_emails RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("list_scheduled_emails MCP error: %s", resp.Common.Message)
+		}
+		var scheduledSummaries []string
+		for _, e := range resp.ScheduledEmails {
+			scheduledSummaries = append(scheduledSummaries, fmt.Sprintf("ID: %s, To: %s, Subject: '%s', Send at: %s, Status: %s", e.Id, e.To, e.Subject, e.SendAt, e.Status))
+		}
+		return map[string]interface{}{"scheduled_emails": scheduledSummaries}, nil
+
+	case "cancel_scheduled_email":
+		id, _ := args["id"].(string)
+
+		req := &pb.CancelScheduledRequest{
+			Common: commonReq,
+			Id:     id,
+		}
+		resp, err := mcpGmailClient.CancelScheduled(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("cancel_scheduled_email RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("cancel_scheduled_email MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"scheduled_email_id": resp.ScheduledEmail.Id, "status": resp.ScheduledEmail.Status}, nil
+
+	case "get_vacation_responder":
+		req := &pb.GetVacationSettingsRequest{Common: commonReq}
+		resp, err := mcpGmailClient.GetVacationSettings(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("get_vacation_responder RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("get_vacation_responder MCP error: %s", resp.Common.Message)
+		}
+		v := resp.Settings
+		return map[string]interface{}{"enabled": v.EnableAutoReply, "subject": v.ResponseSubject, "body": v.ResponseBody, "start_time": v.StartTime, "end_time": v.EndTime}, nil
+
+	case "set_vacation_responder":
+		enable, _ := args["enable"].(bool)
+		subject, _ := args["subject"].(string)
+		body, _ := args["body"].(string)
+		startTime, _ := args["start_time"].(string)
+		endTime, _ := args["end_time"].(string)
+		restrictToContacts, _ := args["restrict_to_contacts"].(bool)
+
+		req := &pb.UpdateVacationSettingsRequest{
+			Common: commonReq,
+			Settings: &pb.VacationSettings{
+				EnableAutoReply:    enable,
+				ResponseSubject:    subject,
+				ResponseBody:       body,
+				RestrictToContacts: restrictToContacts,
+				StartTime:          startTime,
+				EndTime:            endTime,
+			},
+		}
+		resp, err := mcpGmailClient.UpdateVacationSettings(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("set_vacation_responder RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("set_vacation_responder MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"enabled": resp.Settings.EnableAutoReply, "start_time": resp.Settings.StartTime, "end_time": resp.Settings.EndTime}, nil
+
+	case "list_gmail_filters":
+		req := &pb.ListFiltersRequest{Common: commonReq}
+		resp, err := mcpGmailClient.ListFilters(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("list_gmail_filters RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("list_gmail_filters MCP error: %s", resp.Common.Message)
+		}
+		var filterSummaries []string
+		for _, f := range resp.Filters {
+			filterSummaries = append(filterSummaries, fmt.Sprintf("ID: %s, From: '%s', To: '%s', Subject: '%s', Query: '%s', Add labels: %v, Archive: %t, Mark as read: %t",
+				f.Id, f.Criteria.From, f.Criteria.To, f.Criteria.Subject, f.Criteria.Query, f.Action.AddLabels, f.Action.Archive, f.Action.MarkAsRead))
+		}
+		return map[string]interface{}{"filters": filterSummaries}, nil
+
+	case "create_gmail_filter":
+		from, _ := args["from"].(string)
+		to, _ := args["to"].(string)
+		subject, _ := args["subject"].(string)
+		query, _ := args["query"].(string)
+		archive, _ := args["archive"].(bool)
+		markAsRead, _ := args["mark_as_read"].(bool)
+		addLabels := stringSliceArg(args, "add_labels")
+
+		req := &pb.CreateFilterRequest{
+			Common: commonReq,
+			Criteria: &pb.FilterCriteria{
+				From:    from,
+				To:      to,
+				Subject: subject,
+				Query:   query,
+			},
+			Action: &pb.FilterAction{
+				AddLabels:  addLabels,
+				Archive:    archive,
+				MarkAsRead: markAsRead,
+			},
+		}
+		resp, err := mcpGmailClient.CreateFilter(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("create_gmail_filter RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("create_gmail_filter MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"filter_id": resp.CreatedFilter.Id}, nil
+
+	case "delete_gmail_filter":
+		filterID, _ := args["filter_id"].(string)
+
+		req := &pb.DeleteFilterRequest{
+			Common:   commonReq,
+			FilterId: filterID,
+		}
+		resp, err := mcpGmailClient.DeleteFilter(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("delete_gmail_filter RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("delete_gmail_filter MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"deleted_filter_id": filterID}, nil
+
+	case "batch_modify_emails":
+		query, _ := args["query"].(string)
+		markAsRead, _ := args["mark_as_read"].(bool)
+		trash, _ := args["trash"].(bool)
+		dryRun, _ := args["dry_run"].(bool)
+
+		req := &pb.BatchModifyByQueryRequest{
+			Common:       commonReq,
+			Query:        query,
+			AddLabels:    stringSliceArg(args, "add_labels"),
+			RemoveLabels: stringSliceArg(args, "remove_labels"),
+			MarkAsRead:   markAsRead,
+			Trash:        trash,
+			DryRun:       dryRun,
+		}
+		resp, err := mcpGmailClient.BatchModifyByQuery(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("batch_modify_emails RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("batch_modify_emails MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"matched_count": resp.MatchedCount, "modified_count": resp.ModifiedCount, "dry_run": resp.DryRun}, nil
+
+	case "unsubscribe_from_sender":
+		messageID, _ := args["message_id"].(string)
+		createArchiveFilter, _ := args["create_archive_filter"].(bool)
+
+		req := &pb.UnsubscribeFromSenderRequest{
+			Common:              commonReq,
+			MessageId:           messageID,
+			CreateArchiveFilter: createArchiveFilter,
+		}
+		resp, err := mcpGmailClient.UnsubscribeFromSender(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("unsubscribe_from_sender RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("unsubscribe_from_sender MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"sender": resp.Sender, "method": resp.Method, "unsubscribe_url": resp.UnsubscribeUrl, "filter_id": resp.FilterId, "message": resp.Common.Message}, nil
+
+	case "search_contacts":
+		query, _ := args["query"].(string)
+
+		req := &pb.SearchContactsRequest{
+			Common: commonReq,
+			Query:  query,
+		}
+		resp, err := mcpContactsClient.SearchContacts(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("search_contacts RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("search_contacts MCP error: %s", resp.Common.Message)
+		}
+		var matchSummaries []string
+		for _, m := range resp.Matches {
+			matchSummaries = append(matchSummaries, summarizePerson(m.Person))
+		}
+		return map[string]interface{}{"matches": matchSummaries}, nil
+
 	case "list_contacts":
 		pageSize := int32(10) // Default
 		if val, ok := args["page_size"].(float64); ok {
 			pageSize = int32(val)
 		}
+		group, _ := args["group"].(string)
 		req := &pb.ListConnectionsRequest{
 			Common:   commonReq,
 			PageSize: pageSize,
+			Group:    group,
 		}
 		resp, err := mcpContactsClient.ListConnections(rpcCtx, req)
 		if err != nil {
@@ -143,7 +523,7 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		}
 		var contactSummaries []string
 		for _, p := range resp.People {
-			contactSummaries = append(contactSummaries, fmt.Sprintf("Name: %s, Email: %s, Phone: %s", p.DisplayName, p.Email, p.PhoneNumber))
+			contactSummaries = append(contactSummaries, summarizePerson(p))
 		}
 		return map[string]interface{}{"contacts": contactSummaries}, nil
 
@@ -151,12 +531,33 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		displayName, _ := args["display_name"].(string)
 		email, _ := args["email"].(string)
 		phoneNumber, _ := args["phone_number"].(string)
+		emailType, _ := args["email_type"].(string)
+		phoneType, _ := args["phone_type"].(string)
+		company, _ := args["company"].(string)
+		jobTitle, _ := args["job_title"].(string)
+		birthday, _ := args["birthday"].(string)
+		address, _ := args["address"].(string)
+		notes, _ := args["notes"].(string)
+		allowDuplicate, _ := args["allow_duplicate"].(bool)
 
 		req := &pb.CreateContactRequest{
-			Common:      commonReq,
-			DisplayName: displayName,
-			Email:       email,
-			PhoneNumber: phoneNumber,
+			Common:          commonReq,
+			DisplayName:     displayName,
+			Birthday:        birthday,
+			Notes:           notes,
+			FailOnDuplicate: !allowDuplicate,
+		}
+		if email != "" {
+			req.Emails = []*pb.ContactEmail{{Value: email, Type: emailType}}
+		}
+		if phoneNumber != "" {
+			req.Phones = []*pb.ContactPhone{{Value: phoneNumber, Type: phoneType}}
+		}
+		if company != "" || jobTitle != "" {
+			req.Organizations = []*pb.Organization{{Name: company, Title: jobTitle}}
+		}
+		if address != "" {
+			req.Addresses = []*pb.PostalAddress{{Formatted: address}}
 		}
 		resp, err := mcpContactsClient.CreateContact(rpcCtx, req)
 		if err != nil {
@@ -165,31 +566,610 @@ func ExecuteToolCall(ctx context.Context, userID string, tokens *pb.OAuthTokens,
 		if resp.Common.Status == "ERROR" {
 			return nil, fmt.Errorf("create_contact MCP error: %s", resp.Common.Message)
 		}
-		return map[string]interface{}{"contact_name": resp.CreatedContact.DisplayName, "contact_id": resp.CreatedContact.ResourceName}, nil
+		return map[string]interface{}{"contact_name": resp.CreatedContact.DisplayName, "contact_id": resp.CreatedContact.ResourceName, "message": resp.Common.Message}, nil
+
+	case "update_contact":
+		resourceName, _ := args["contact_id"].(string)
+		etag, _ := args["etag"].(string)
+		displayName, _ := args["display_name"].(string)
+		email, _ := args["email"].(string)
+		phoneNumber, _ := args["phone_number"].(string)
+
+		req := &pb.UpdateContactRequest{
+			Common:       commonReq,
+			ResourceName: resourceName,
+			Etag:         etag,
+			DisplayName:  displayName,
+			Email:        email,
+			PhoneNumber:  phoneNumber,
+		}
+		resp, err := mcpContactsClient.UpdateContact(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("update_contact RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("update_contact MCP error: %s", resp.Common.Message)
+		}
+		c := resp.UpdatedContact
+		return map[string]interface{}{"contact_id": c.ResourceName, "etag": c.Etag, "contact_name": c.DisplayName, "email": c.Email, "phone_number": c.PhoneNumber}, nil
+
+	case "list_contact_groups":
+		req := &pb.ListContactGroupsRequest{Common: commonReq}
+		resp, err := mcpContactsClient.ListContactGroups(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("list_contact_groups RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("list_contact_groups MCP error: %s", resp.Common.Message)
+		}
+		var groupSummaries []string
+		for _, g := range resp.Groups {
+			groupSummaries = append(groupSummaries, fmt.Sprintf("ID: %s, Name: %s, Members: %d", g.ResourceName, g.Name, g.MemberCount))
+		}
+		return map[string]interface{}{"groups": groupSummaries}, nil
+
+	case "create_contact_group":
+		name, _ := args["name"].(string)
+
+		req := &pb.CreateContactGroupRequest{
+			Common: commonReq,
+			Name:   name,
+		}
+		resp, err := mcpContactsClient.CreateContactGroup(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("create_contact_group RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("create_contact_group MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"group_id": resp.CreatedGroup.ResourceName, "name": resp.CreatedGroup.Name}, nil
+
+	case "add_contacts_to_group":
+		group, _ := args["group"].(string)
+
+		req := &pb.AddContactsToGroupRequest{
+			Common:        commonReq,
+			Group:         group,
+			ResourceNames: stringSliceArg(args, "contact_ids"),
+		}
+		resp, err := mcpContactsClient.AddContactsToGroup(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("add_contacts_to_group RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("add_contacts_to_group MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"not_found_contact_ids": resp.NotFoundResourceNames}, nil
+
+	case "remove_contacts_from_group":
+		group, _ := args["group"].(string)
+
+		req := &pb.RemoveContactsFromGroupRequest{
+			Common:        commonReq,
+			Group:         group,
+			ResourceNames: stringSliceArg(args, "contact_ids"),
+		}
+		resp, err := mcpContactsClient.RemoveContactsFromGroup(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("remove_contacts_from_group RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("remove_contacts_from_group MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"not_found_contact_ids": resp.NotFoundResourceNames, "not_removed_contact_ids": resp.NotRemovedResourceNames}, nil
+
+	case "find_duplicate_contacts":
+		maxGroups, _ := args["max_groups"].(float64)
+
+		req := &pb.FindDuplicateContactsRequest{
+			Common:      commonReq,
+			MaxClusters: int32(maxGroups),
+		}
+		resp, err := mcpContactsClient.FindDuplicateContacts(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("find_duplicate_contacts RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("find_duplicate_contacts MCP error: %s", resp.Common.Message)
+		}
+		var groups []map[string]interface{}
+		for _, c := range resp.Clusters {
+			var contacts []string
+			for _, p := range c.Contacts {
+				contacts = append(contacts, summarizePerson(p))
+			}
+			groups = append(groups, map[string]interface{}{"contacts": contacts, "reasons": c.Reasons})
+		}
+		return map[string]interface{}{"duplicate_groups": groups}, nil
+
+	case "merge_contacts":
+		req := &pb.MergeContactsRequest{
+			Common:        commonReq,
+			ResourceNames: stringSliceArg(args, "contact_ids"),
+		}
+		resp, err := mcpContactsClient.MergeContacts(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("merge_contacts RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("merge_contacts MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"merged_contact": summarizePerson(resp.MergedContact), "deleted_contact_ids": resp.DeletedResourceNames}, nil
+
+	case "import_vcard":
+		vcardData, _ := args["vcard_data"].(string)
+		updateExisting, _ := args["update_existing"].(bool)
+
+		req := &pb.ImportVCardRequest{
+			Common:         commonReq,
+			VcardData:      vcardData,
+			UpdateExisting: updateExisting,
+		}
+		resp, err := mcpContactsClient.ImportVCard(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("import_vcard RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("import_vcard MCP error: %s", resp.Common.Message)
+		}
+		var imported []map[string]interface{}
+		for _, c := range resp.Contacts {
+			entry := map[string]interface{}{"contact": summarizePerson(c.Contact), "action": c.Action}
+			if c.Error != "" {
+				entry["error"] = c.Error
+			}
+			imported = append(imported, entry)
+		}
+		return map[string]interface{}{"message": resp.Common.Message, "contacts": imported}, nil
+
+	case "export_vcard":
+		group, _ := args["group"].(string)
+		version, _ := args["version"].(string)
+
+		req := &pb.ExportVCardRequest{
+			Common:        commonReq,
+			ResourceNames: stringSliceArg(args, "contact_ids"),
+			Group:         group,
+			Version:       version,
+		}
+		resp, err := mcpContactsClient.ExportVCard(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("export_vcard RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("export_vcard MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"count": resp.Count, "vcard_data": resp.VcardData}, nil
+
+	case "delete_contact":
+		resourceName, _ := args["contact_id"].(string)
+		etag, _ := args["etag"].(string)
+
+		req := &pb.DeleteContactRequest{
+			Common:       commonReq,
+			ResourceName: resourceName,
+			Etag:         etag,
+		}
+		resp, err := mcpContactsClient.DeleteContact(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("delete_contact RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("delete_contact MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"deleted_contact_id": resourceName}, nil
+
+	case "search_drive_files":
+		nameContains, _ := args["name_contains"].(string)
+		query, _ := args["query"].(string)
+		maxResults, _ := args["max_results"].(float64)
+
+		req := &pb.SearchFilesRequest{
+			Common:       commonReq,
+			Query:        query,
+			NameContains: nameContains,
+			MaxResults:   int32(maxResults),
+		}
+		resp, err := mcpDriveClient.SearchFiles(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("search_drive_files RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("search_drive_files MCP error: %s", resp.Common.Message)
+		}
+		var files []map[string]interface{}
+		for _, f := range resp.Files {
+			files = append(files, summarizeDriveFile(f))
+		}
+		return map[string]interface{}{"files": files}, nil
+
+	case "get_drive_file":
+		fileID, _ := args["file_id"].(string)
+
+		req := &pb.GetFileMetadataRequest{
+			Common: commonReq,
+			FileId: fileID,
+		}
+		resp, err := mcpDriveClient.GetFileMetadata(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("get_drive_file RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("get_drive_file MCP error: %s", resp.Common.Message)
+		}
+		file := summarizeDriveFile(resp.File)
+		file["size"] = resp.File.Size
+		file["owners"] = resp.File.Owners
+		file["shared"] = resp.File.Shared
+		return file, nil
+
+	case "read_drive_file":
+		fileID, _ := args["file_id"].(string)
+
+		meta, err := mcpDriveClient.GetFileMetadata(rpcCtx, &pb.GetFileMetadataRequest{Common: commonReq, FileId: fileID})
+		if err != nil {
+			return nil, fmt.Errorf("read_drive_file RPC failed: %w", err)
+		}
+		// Only formats Gemini can read as text are downloaded.
+		exportFormat := ""
+		switch mimeType := meta.File.MimeType; {
+		case mimeType == "application/vnd.google-apps.document", mimeType == "application/vnd.google-apps.presentation":
+			exportFormat = "text"
+		case mimeType == "application/vnd.google-apps.spreadsheet":
+			exportFormat = "csv"
+		case strings.HasPrefix(mimeType, "text/"), mimeType == "application/json":
+		default:
+			return nil, fmt.Errorf("files of type %s cannot be read as text; give the user the link instead: %s", mimeType, meta.File.WebViewLink)
+		}
+
+		req := &pb.DownloadFileRequest{
+			Common:       commonReq,
+			FileId:       fileID,
+			ExportFormat: exportFormat,
+		}
+		resp, err := mcpDriveClient.DownloadFile(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("read_drive_file RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("read_drive_file MCP error: %s", resp.Common.Message)
+		}
+		text := string(resp.Content)
+		truncated := false
+		if len([]rune(text)) > maxDriveTextChars {
+			text = string([]rune(text)[:maxDriveTextChars])
+			truncated = true
+		}
+		return map[string]interface{}{"name": resp.File.Name, "text": text, "truncated": truncated, "link": resp.File.WebViewLink}, nil
+
+	case "save_text_to_drive":
+		name, _ := args["name"].(string)
+		content, _ := args["content"].(string)
+		asGoogleDoc, _ := args["as_google_doc"].(bool)
+		folderID, _ := args["folder_id"].(string)
+
+		mimeType := "text/plain"
+		if asGoogleDoc {
+			mimeType = "application/vnd.google-apps.document"
+		}
+		req := &pb.UploadFileRequest{
+			Common:         commonReq,
+			Name:           name,
+			MimeType:       mimeType,
+			Content:        []byte(content),
+			ParentFolderId: folderID,
+		}
+		resp, err := mcpDriveClient.UploadFile(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("save_text_to_drive RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("save_text_to_drive MCP error: %s", resp.Common.Message)
+		}
+		return summarizeDriveFile(resp.File), nil
+
+	case "share_drive_file":
+		fileID, _ := args["file_id"].(string)
+		recipient, _ := args["email"].(string)
+		role, _ := args["role"].(string)
+		notify, _ := args["notify"].(bool)
+		message, _ := args["message"].(string)
+
+		email, err := resolveEmailAddress(rpcCtx, commonReq, recipient)
+		if err != nil {
+			return nil, err
+		}
+		req := &pb.ShareFileRequest{
+			Common:           commonReq,
+			FileId:           fileID,
+			Email:            email,
+			Role:             role,
+			SendNotification: notify,
+			Message:          message,
+		}
+		resp, err := mcpDriveClient.ShareFile(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("share_drive_file RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("share_drive_file MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"message": resp.Common.Message, "link": resp.File.WebViewLink}, nil
+
+	case "create_document":
+		title, _ := args["title"].(string)
+		text, _ := args["text"].(string)
+
+		req := &pb.CreateDocumentRequest{
+			Common:      commonReq,
+			Title:       title,
+			InitialText: text,
+		}
+		resp, err := mcpDocsClient.CreateDocument(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("create_document RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("create_document MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"document_id": resp.DocumentId, "title": resp.Title, "link": resp.Url}, nil
+
+	case "append_to_document":
+		documentID, _ := args["document_id"].(string)
+		text, _ := args["text"].(string)
+
+		req := &pb.AppendTextRequest{
+			Common:     commonReq,
+			DocumentId: documentID,
+			Text:       text,
+		}
+		resp, err := mcpDocsClient.AppendText(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("append_to_document RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("append_to_document MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"message": resp.Common.Message, "link": resp.Url}, nil
+
+	case "read_document":
+		documentID, _ := args["document_id"].(string)
+
+		req := &pb.GetDocumentTextRequest{
+			Common:     commonReq,
+			DocumentId: documentID,
+		}
+		resp, err := mcpDocsClient.GetDocumentText(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("read_document RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("read_document MCP error: %s", resp.Common.Message)
+		}
+		text := resp.Text
+		truncated := false
+		if len([]rune(text)) > maxDriveTextChars {
+			text = string([]rune(text)[:maxDriveTextChars])
+			truncated = true
+		}
+		return map[string]interface{}{"title": resp.Title, "text": text, "truncated": truncated}, nil
+
+	case "read_sheet_range":
+		spreadsheetID, _ := args["spreadsheet_id"].(string)
+		rangeA1, _ := args["range"].(string)
+
+		req := &pb.ReadRangeRequest{
+			Common:        commonReq,
+			SpreadsheetId: spreadsheetID,
+			Range:         rangeA1,
+		}
+		resp, err := mcpSheetsClient.ReadRange(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("read_sheet_range RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("read_sheet_range MCP error: %s", resp.Common.Message)
+		}
+		var rows [][]string
+		for _, r := range resp.Rows {
+			rows = append(rows, r.Values)
+		}
+		return map[string]interface{}{"range": resp.Range, "rows": rows}, nil
+
+	case "append_sheet_rows":
+		spreadsheetID, _ := args["spreadsheet_id"].(string)
+		rangeA1, _ := args["range"].(string)
+
+		req := &pb.AppendRowsRequest{
+			Common:        commonReq,
+			SpreadsheetId: spreadsheetID,
+			Range:         rangeA1,
+			Rows:          sheetRowsArg(args, "rows"),
+		}
+		resp, err := mcpSheetsClient.AppendRows(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("append_sheet_rows RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("append_sheet_rows MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"updated_range": resp.UpdatedRange, "updated_rows": resp.UpdatedRows}, nil
+
+	case "update_sheet_range":
+		spreadsheetID, _ := args["spreadsheet_id"].(string)
+		rangeA1, _ := args["range"].(string)
+
+		req := &pb.UpdateRangeRequest{
+			Common:        commonReq,
+			SpreadsheetId: spreadsheetID,
+			Range:         rangeA1,
+			Rows:          sheetRowsArg(args, "rows"),
+		}
+		resp, err := mcpSheetsClient.UpdateRange(rpcCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("update_sheet_range RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("update_sheet_range MCP error: %s", resp.Common.Message)
+		}
+		return map[string]interface{}{"updated_range": resp.UpdatedRange, "updated_cells": resp.UpdatedCells}, nil
+
+	case "prepare_meeting_briefing":
+		calendarID, _ := args["calendar_id"].(string)
+		eventID, _ := args["event_id"].(string)
+
+		// The briefing makes several Google API calls per attendee.
+		briefingCtx, cancelBriefing := context.WithTimeout(ctx, 45*time.Second)
+		defer cancelBriefing()
+		req := &pb.GetMeetingBriefingRequest{
+			Common:     commonReq,
+			CalendarId: calendarID,
+			EventId:    eventID,
+		}
+		resp, err := mcpCalendarClient.GetMeetingBriefing(briefingCtx, req)
+		if err != nil {
+			return nil, fmt.Errorf("prepare_meeting_briefing RPC failed: %w", err)
+		}
+		if resp.Common.Status == "ERROR" {
+			return nil, fmt.Errorf("prepare_meeting_briefing MCP error: %s", resp.Common.Message)
+		}
+
+		var attendees []map[string]interface{}
+		for _, a := range resp.Attendees {
+			attendee := map[string]interface{}{
+				"email":    a.Email,
+				"name":     a.DisplayName,
+				"response": a.ResponseStatus,
+			}
+			if a.Organizer {
+				attendee["organizer"] = true
+			}
+			if a.Contact != nil {
+				attendee["contact"] = summarizePerson(a.Contact)
+			}
+			var threads []string
+			for _, t := range a.RecentThreads {
+				threads = append(threads, fmt.Sprintf("'%s' from %s on %s (%d messages): %s", t.Subject, t.From, t.Date, t.MessageCount, t.Snippet))
+			}
+			if len(threads) > 0 {
+				attendee["recent_emails"] = threads
+			}
+			attendees = append(attendees, attendee)
+		}
+		var documents []map[string]interface{}
+		for _, d := range resp.Documents {
+			documents = append(documents, summarizeDriveFile(d))
+		}
+
+		result := map[string]interface{}{
+			"event_id":  resp.Event.Id,
+			"summary":   resp.Event.Summary,
+			"start":     resp.Event.StartTime,
+			"end":       resp.Event.EndTime,
+			"location":  resp.Location,
+			"agenda":    resp.Event.Description,
+			"link":      resp.Event.HtmlLink,
+			"attendees": attendees,
+			"documents": documents,
+		}
+		if len(resp.Warnings) > 0 {
+			result["warnings"] = resp.Warnings
+		}
+		return result, nil
 
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", toolName)
 	}
 }
 
-// loadAndPrepareTokens loads OAuth tokens from token.json and prepares them for gRPC request.
-// This function is kept here as it's specific to loading tokens for MCP client use.
-func LoadAndPrepareTokens() (*oauth2.Token, *pb.OAuthTokens, error) {
-	b, err := ioutil.ReadFile(tokenCacheFile)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to read %s: %w. Please ensure the MCP server has run and authorized.", tokenCacheFile, err)
+// stringSliceArg extracts a list of strings from a tool call argument.
+// Gemini sends arrays as []interface{}; non-string items are skipped.
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	var out []string
+	if vals, ok := args[key].([]interface{}); ok {
+		for _, v := range vals {
+			if str, ok := v.(string); ok {
+				out = append(out, str)
+			}
+		}
 	}
-	var tok oauth2.Token
-	err = json.Unmarshal(b, &tok)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to unmarshal %s: %w", tokenCacheFile, err)
+	return out
+}
+
+// sheetRowsArg extracts a list of rows of cell values from the tool call
+// arguments. Gemini may send numbers as numbers, so non-string cells are
+// formatted as text.
+func sheetRowsArg(args map[string]interface{}, key string) []*pb.SheetRow {
+	var rows []*pb.SheetRow
+	vals, _ := args[key].([]interface{})
+	for _, v := range vals {
+		cells, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		row := &pb.SheetRow{}
+		for _, c := range cells {
+			if str, ok := c.(string); ok {
+				row.Values = append(row.Values, str)
+			} else {
+				row.Values = append(row.Values, fmt.Sprint(c))
+			}
+		}
+		rows = append(rows, row)
 	}
+	return rows
+}
+
+// summarizePerson renders a contact as a single line for Gemini, listing
+// typed emails and phones so it can answer questions like "Maria's work number".
+func summarizePerson(p *pb.Person) string {
+	typed := func(value, kind string) string {
+		if kind == "" {
+			return value
+		}
+		return fmt.Sprintf("%s (%s)", value, kind)
+	}
+
+	parts := []string{fmt.Sprintf("ID: %s", p.ResourceName), fmt.Sprintf("Etag: %s", p.Etag), fmt.Sprintf("Name: %s", p.DisplayName)}
+	var emails, phones []string
+	for _, e := range p.Emails {
+		emails = append(emails, typed(e.Value, e.Type))
+	}
+	for _, ph := range p.Phones {
+		phones = append(phones, typed(ph.Value, ph.Type))
+	}
+	if len(emails) > 0 {
+		parts = append(parts, "Emails: "+strings.Join(emails, ", "))
+	}
+	if len(phones) > 0 {
+		parts = append(parts, "Phones: "+strings.Join(phones, ", "))
+	}
+	for _, o := range p.Organizations {
+		parts = append(parts, fmt.Sprintf("Organization: %s", strings.TrimSpace(strings.Join([]string{o.Title, o.Name, o.Department}, " "))))
+	}
+	if p.Birthday != "" {
+		parts = append(parts, "Birthday: "+p.Birthday)
+	}
+	for _, a := range p.Addresses {
+		formatted := a.Formatted
+		if formatted == "" {
+			formatted = strings.Join([]string{a.Street, a.City, a.Region, a.PostalCode, a.Country}, " ")
+		}
+		parts = append(parts, "Address: "+typed(strings.TrimSpace(formatted), a.Type))
+	}
+	if p.Notes != "" {
+		parts = append(parts, "Notes: "+p.Notes)
+	}
+	return strings.Join(parts, ", ")
+}
 
-	pbTokens := &pb.OAuthTokens{
-		AccessToken:  tok.AccessToken,
-		RefreshToken: tok.RefreshToken,
-		TokenType:    tok.TokenType,
-		ExpiryUnix:   tok.Expiry.Unix(),
+// summarizeDriveFile keeps the fields of a Drive file Gemini needs to refer to it.
+func summarizeDriveFile(f *pb.DriveFile) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            f.Id,
+		"name":          f.Name,
+		"type":          f.MimeType,
+		"modified_time": f.ModifiedTime,
+		"link":          f.WebViewLink,
 	}
-	return &tok, pbTokens, nil
 }
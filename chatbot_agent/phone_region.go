@@ -0,0 +1,18 @@
+// chatbot_agent/phone_region.go
+package main
+
+import (
+	"github.com/ttacon/libphonenumber"
+)
+
+// whatsAppPhoneRegion derives the region of a WhatsApp user from their ID,
+// which is their phone number in international format without the "+". The
+// MCP server uses it for phone numbers the user gives without a country
+// code. It returns "" if the ID is not a valid phone number.
+func whatsAppPhoneRegion(userID string) string {
+	num, err := libphonenumber.Parse("+"+userID, "")
+	if err != nil || !libphonenumber.IsValidNumber(num) {
+		return ""
+	}
+	return libphonenumber.GetRegionCodeForNumber(num)
+}
@@ -3,8 +3,6 @@ package main
 
 import (
 	"sync"
-
-	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
 )
 
 var (
@@ -24,12 +22,3 @@ func GetOrCreateUserSession(userID string) (*UserSession, bool) {
 	}
 	return session, ok
 }
-
-// UpdateUserSessionTokens updates the OAuth tokens for a user session.
-func UpdateUserSessionTokens(userID string, tokens *pb.OAuthTokens) {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
-	if session, ok := userSessions[userID]; ok {
-		session.OAuthTokens = tokens
-	}
-}
@@ -0,0 +1,60 @@
+// chatbot_agent/tool_errors.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// explainToolError adds to err, returned by an MCP call, a note telling
+// Gemini how to react to it according to its gRPC code and details.
+// Authorization errors are handled separately, see authorizationLink.
+func explainToolError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var note string
+	switch st.Code() {
+	case codes.NotFound:
+		note = "the requested item does not exist; tell the user instead of retrying"
+	case codes.PermissionDenied:
+		note = "the user's Google account has no access to this item; tell the user instead of retrying"
+	case codes.ResourceExhausted:
+		note = "a usage limit was reached; tell the user to try again later instead of retrying now"
+		for _, d := range st.Details() {
+			if ri, ok := d.(*errdetails.RetryInfo); ok && ri.RetryDelay != nil {
+				note = fmt.Sprintf("a usage limit was reached; tell the user to try again in %s instead of retrying now", ri.RetryDelay.AsDuration().Round(time.Second))
+			}
+		}
+	case codes.InvalidArgument:
+		var violations []string
+		for _, d := range st.Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok {
+				for _, v := range br.FieldViolations {
+					if v.Field != "" {
+						violations = append(violations, v.Field+": "+v.Description)
+					} else {
+						violations = append(violations, v.Description)
+					}
+				}
+			}
+		}
+		note = "the arguments were rejected"
+		if len(violations) > 0 {
+			note += " (" + strings.Join(violations, "; ") + ")"
+		}
+		note += "; correct them and call the tool again, or ask the user for the missing information"
+	case codes.Unavailable, codes.DeadlineExceeded:
+		note = "Google is temporarily unavailable; tell the user to try again in a moment"
+	default:
+		return err
+	}
+	return fmt.Errorf("%w (%s)", err, note)
+}
@@ -3,13 +3,11 @@ package main
 
 import (
 	"github.com/google/generative-ai-go/genai"
-	pb "github.com/pmartinizquierdob/mcp-google-services/pb" // Ensure this path is correct
 )
 
-// UserSession stores chat session and OAuth tokens for a user.
+// UserSession stores the chat session of a user.
 type UserSession struct {
 	ChatSession *genai.ChatSession
-	OAuthTokens *pb.OAuthTokens // Stores the last known valid tokens for the user
 	// Add other session data as needed
 }
 
@@ -2,13 +2,10 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"log"
+	"os"
 	"time"
 
-	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure" // For plaintext, indev connection
 	"google.golang.org/grpc/status"
@@ -18,41 +15,17 @@ import (
 
 const (
 	mcpServerAddress = "localhost:50051"
-	tokenCacheFile   = "token.json"
 )
 
-// loadAndPrepareTokens loads OAuth tokens from token.json and prepares them for gRPC request.
-func loadAndPrepareTokens() (*oauth2.Token, *pb.OAuthTokens, error) {
-	b, err := ioutil.ReadFile(tokenCacheFile)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to read %s: %w Please ensure the MCP server has run and authorized", tokenCacheFile, err)
-	}
-	var tok oauth2.Token
-	err = json.Unmarshal(b, &tok)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to unmarshal %s: %w", tokenCacheFile, err)
-	}
-
-	// For the gRPC request, we need a protobuf-compatible structure.
-	// We'll use the Unix timestamp for expiry.
-	pbTokens := &pb.OAuthTokens{
-		AccessToken:  tok.AccessToken,
-		RefreshToken: tok.RefreshToken,
-		TokenType:    tok.TokenType,
-		ExpiryUnix:   tok.Expiry.Unix(),
-	}
-	return &tok, pbTokens, nil
-}
-
 func main() {
 	log.Println("Starting MCP Client Example...")
 
-	// 1. Load and prepare OAuth tokens
-	oauthTok, pbToks, err := loadAndPrepareTokens()
-	if err != nil {
-		log.Fatalf("Failed to load tokens: %v", err)
+	// 1. Identify the user. The MCP server uses the Google tokens it stored
+	// when this user authorized at http://localhost:8080/authorize?user_id=<id>.
+	userID := os.Getenv("MCP_USER_ID")
+	if userID == "" {
+		log.Fatalf("Set MCP_USER_ID to the ID of a user who authorized the MCP server.")
 	}
-	log.Printf("Successfully loaded OAuth tokens (access_token: %s..., refresh_token: %s...)", oauthTok.AccessToken[:10], oauthTok.RefreshToken[:10])
 
 	// 2. Set up a connection to the gRPC server
 	conn, err := grpc.Dial(mcpServerAddress, grpc.WithTransportCredentials(insecure.NewCredentials())) // Using insecure for local dev
@@ -67,7 +40,7 @@ func main() {
 	// 4. Prepare the ListEvents request
 	listReq := &pb.ListEventsRequest{
 		Common: &pb.CommonRequest{
-			AuthTokens: pbToks, // Pass the loaded tokens
+			UserId: userID,
 		},
 		CalendarId: "primary", // Common calendar ID for the authenticated user
 		MaxResults: 5,         // Get up to 5 events
@@ -113,7 +86,7 @@ func main() {
 
 	createReq := &pb.CreateEventRequest{
 		Common: &pb.CommonRequest{
-			AuthTokens: pbToks, // Pass the loaded tokens
+			UserId: userID,
 		},
 		CalendarId:  "primary",
 		Summary:     "Reunión de Prueba LLM",
@@ -146,7 +119,7 @@ func main() {
 
 	sendEmailReq := &pb.SendEmailRequest{
 		Common: &pb.CommonRequest{
-			AuthTokens: pbToks,
+			UserId: userID,
 		},
 		To:      "pmartin.izq@gmail.com", // <<--- ¡CAMBIA ESTO A UNA DIRECCIÓN DE CORREO VÁLIDA PARA PRUEBAS!
 		Subject: "Prueba de envío de correo desde LLM Agent",
@@ -175,7 +148,7 @@ func main() {
 
 	listContactsReq := &pb.ListConnectionsRequest{
 		Common: &pb.CommonRequest{
-			AuthTokens: pbToks,
+			UserId: userID,
 		},
 		PageSize: 3,
 	}
@@ -203,7 +176,7 @@ func main() {
 	log.Println("\nCalling ContactsService.CreateContact...")
 	createContactReq := &pb.CreateContactRequest{
 		Common: &pb.CommonRequest{
-			AuthTokens: pbToks,
+			UserId: userID,
 		},
 		DisplayName: "Contacto de Prueba LLM",
 		Email:       "test-llm-contact@example.com", // <<--- ¡CAMBIA ESTO O AJUSTA PARA NO CREAR DUPLICADOS!
@@ -0,0 +1,396 @@
+// mcp_services/auth.go
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// How long an authorization link, and then its confirmation code, can be used
+	authorizationTTL = 15 * time.Minute
+	// Wrong confirmation codes accepted before the authorization is dropped
+	maxConfirmationAttempts = 5
+	// Google's OAuth 2.0 token revocation endpoint
+	googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+)
+
+var (
+	errInvalidAuthState      = errors.New("invalid authorization state")
+	errExpiredAuthState      = errors.New("authorization link expired")
+	errNoHeldAuthorization   = errors.New("no authorization waiting for confirmation")
+	errWrongConfirmationCode = errors.New("wrong confirmation code")
+)
+
+// authState is the payload of the OAuth state parameter. It is signed, so
+// the callback can trust the user it names.
+type authState struct {
+	UserID  string `json:"u"`
+	Nonce   string `json:"n"`
+	Expires int64  `json:"e"`
+}
+
+// pendingAuthorization is an authorization link that has not been used yet.
+type pendingAuthorization struct {
+	userID   string
+	verifier string // PKCE code verifier, never sent to the browser
+	expires  time.Time
+}
+
+// heldAuthorization holds the tokens of a user who consented until the
+// confirmation code shown to them comes back from the chat of the user the
+// link was issued for.
+type heldAuthorization struct {
+	code     string
+	token    *oauth2.Token
+	email    string
+	expires  time.Time
+	attempts int
+}
+
+// authorizationFlows hands out authorization links and checks the state
+// they come back with. Each state is signed with a key generated at startup
+// and can only be used once, so links do not survive a restart.
+type authorizationFlows struct {
+	mu      sync.Mutex
+	secret  []byte
+	pending map[string]*pendingAuthorization // keyed by nonce
+	held    map[string]*heldAuthorization    // keyed by user ID
+}
+
+func newAuthorizationFlows() (*authorizationFlows, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &authorizationFlows{
+		secret:  secret,
+		pending: make(map[string]*pendingAuthorization),
+		held:    make(map[string]*heldAuthorization),
+	}, nil
+}
+
+func (f *authorizationFlows) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Start returns a Google consent URL whose tokens will be stored for userID.
+// It asks for scopes on top of the base scopes and those granted before.
+func (f *authorizationFlows) Start(userID string, scopes []string) (string, time.Time, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expires := now.Add(authorizationTTL)
+	payload, err := json.Marshal(authState{UserID: userID, Nonce: hex.EncodeToString(nonce), Expires: expires.Unix()})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	state := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(f.sign(payload))
+	verifier := oauth2.GenerateVerifier()
+
+	f.mu.Lock()
+	for n, p := range f.pending {
+		if now.After(p.expires) {
+			delete(f.pending, n)
+		}
+	}
+	f.pending[hex.EncodeToString(nonce)] = &pendingAuthorization{userID: userID, verifier: verifier, expires: expires}
+	f.mu.Unlock()
+
+	cfg := *googleOAuthConfig
+	cfg.Scopes = append(append([]string(nil), googleOAuthConfig.Scopes...), scopes...)
+	authURL := cfg.AuthCodeURL(state,
+		oauth2.AccessTypeOffline,
+		oauth2.ApprovalForce,
+		oauth2.S256ChallengeOption(verifier),
+		// Keep the scopes granted before, so access is granted one service at a time.
+		oauth2.SetAuthURLParam("include_granted_scopes", "true"),
+	)
+	return authURL, expires, nil
+}
+
+// Finish validates a state received by the OAuth callback and returns the
+// user it was issued for and the PKCE verifier to exchange the code with.
+func (f *authorizationFlows) Finish(state string) (string, string, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(state, ".")
+	if !ok {
+		return "", "", errInvalidAuthState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", "", errInvalidAuthState
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, f.sign(payload)) {
+		return "", "", errInvalidAuthState
+	}
+	var st authState
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return "", "", errInvalidAuthState
+	}
+	if time.Now().After(time.Unix(st.Expires, 0)) {
+		return "", "", errExpiredAuthState
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.pending[st.Nonce]
+	if !ok || p.userID != st.UserID {
+		// Already used, or issued before a restart.
+		return "", "", errExpiredAuthState
+	}
+	delete(f.pending, st.Nonce)
+	return p.userID, p.verifier, nil
+}
+
+// Hold keeps the tokens a user consented to for userID and returns the code
+// that confirms them. A newer authorization replaces one still held.
+func (f *authorizationFlows) Hold(userID string, tok *oauth2.Token) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held[userID] = &heldAuthorization{code: code, token: tok, email: idTokenEmail(tok), expires: time.Now().Add(authorizationTTL)}
+	return code, nil
+}
+
+// Confirm returns the authorization held for userID if code is its
+// confirmation code. It is dropped once confirmed, once expired and after
+// maxConfirmationAttempts wrong codes.
+func (f *authorizationFlows) Confirm(userID, code string) (*heldAuthorization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h, ok := f.held[userID]
+	if !ok || time.Now().After(h.expires) {
+		delete(f.held, userID)
+		return nil, errNoHeldAuthorization
+	}
+	if subtle.ConstantTimeCompare([]byte(code), []byte(h.code)) != 1 {
+		h.attempts++
+		if h.attempts >= maxConfirmationAttempts {
+			delete(f.held, userID)
+		}
+		return nil, errWrongConfirmationCode
+	}
+	delete(f.held, userID)
+	return h, nil
+}
+
+// idTokenEmail returns the email claim of the OpenID Connect ID token in a
+// token response. The ID token comes straight from Google's token endpoint,
+// so its signature is not checked.
+func idTokenEmail(tok *oauth2.Token) string {
+	idToken, _ := tok.Extra("id_token").(string)
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Email
+}
+
+// revokeToken asks Google to revoke tok. Revoking the refresh token also
+// revokes every scope the user granted and the access tokens issued from it.
+func revokeToken(ctx context.Context, client *http.Client, tok *oauth2.Token) error {
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleRevokeURL, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "invalid_token"):
+		// Already revoked or expired, e.g. from the user's Google account settings.
+		return nil
+	}
+	return fmt.Errorf("revocation endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// ====================================================================
+// Auth Service Implementation
+// ====================================================================
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	httpClient *http.Client // Client used to call Google's revocation endpoint
+	scheduled  *scheduledEmailStore
+}
+
+func (s *authServer) StartAuthorization(ctx context.Context, req *pb.StartAuthorizationRequest) (*pb.StartAuthorizationResponse, error) {
+	if req.Common == nil || req.Common.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A user ID is required.")
+	}
+
+	scopes, err := scopesForServices(req.Services)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	authURL, expires, err := authorizations.Start(req.Common.UserId, scopes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to start authorization: %v", err)
+	}
+
+	return &pb.StartAuthorizationResponse{
+		Common:           &pb.CommonResponse{Status: "OK", Message: "Authorization started successfully."},
+		AuthorizationUrl: authURL,
+		ExpiresAt:        expires.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *authServer) ConfirmAuthorization(ctx context.Context, req *pb.ConfirmAuthorizationRequest) (*pb.ConfirmAuthorizationResponse, error) {
+	if req.Common == nil || req.Common.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A user ID is required.")
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A confirmation code is required.")
+	}
+
+	held, err := authorizations.Confirm(req.Common.UserId, strings.TrimSpace(req.Code))
+	switch {
+	case errors.Is(err, errNoHeldAuthorization):
+		return nil, status.Errorf(codes.FailedPrecondition, "No authorization is waiting for confirmation, or it expired. Start a new one.")
+	case errors.Is(err, errWrongConfirmationCode):
+		return nil, status.Errorf(codes.InvalidArgument, "Wrong confirmation code.")
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Unable to confirm authorization: %v", err)
+	}
+
+	if err := userTokens.Put(req.Common.UserId, held.token); err != nil {
+		log.Printf("Unable to store OAuth token for user %s: %v", req.Common.UserId, err)
+		return nil, status.Errorf(codes.Internal, "Unable to store the authorization: %v", err)
+	}
+	log.Printf("OAuth token stored for user %s", req.Common.UserId)
+
+	return &pb.ConfirmAuthorizationResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Google account connected successfully."},
+		Email:  held.email,
+	}, nil
+}
+
+func (s *authServer) RevokeAccess(ctx context.Context, req *pb.RevokeAccessRequest) (*pb.RevokeAccessResponse, error) {
+	if req.Common == nil || req.Common.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A user ID is required.")
+	}
+	userID := req.Common.UserId
+
+	grant, err := userTokens.Grant(userID)
+	if errors.Is(err, errTokenNotFound) {
+		return &pb.RevokeAccessResponse{
+			Common: &pb.CommonResponse{Status: "OK", Message: "No Google account was connected."},
+		}, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to get stored OAuth token: %v", err)
+	}
+
+	// Keep the tokens if Google did not revoke them, so the user can try again.
+	if err := revokeToken(ctx, s.httpClient, grant.Token); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Unable to revoke access at Google, the account is still connected: %v", err)
+	}
+	if err := userTokens.Delete(userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "Access was revoked but the stored tokens could not be deleted: %v", err)
+	}
+	googleClients.Invalidate(userID)
+	log.Printf("Revoked Google access of user %s", userID)
+
+	// These would fail without tokens anyway; cancelling them tells the user why.
+	cancelled, err := s.scheduled.CancelForUser(userID)
+	if err != nil {
+		log.Printf("Unable to cancel scheduled emails of user %s: %v", userID, err)
+	}
+
+	return &pb.RevokeAccessResponse{
+		Common:                   &pb.CommonResponse{Status: "OK", Message: "Google account disconnected successfully."},
+		WasConnected:             true,
+		CancelledScheduledEmails: int32(cancelled),
+	}, nil
+}
+
+func (s *authServer) GetAuthStatus(ctx context.Context, req *pb.GetAuthStatusRequest) (*pb.GetAuthStatusResponse, error) {
+	if req.Common == nil || req.Common.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A user ID is required.")
+	}
+
+	grant, err := userTokens.Grant(req.Common.UserId)
+	if errors.Is(err, errTokenNotFound) {
+		return &pb.GetAuthStatusResponse{
+			Common: &pb.CommonResponse{Status: "OK", Message: "No Google account is connected."},
+		}, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to get stored OAuth token: %v", err)
+	}
+
+	var granted []string
+	for _, service := range knownServices() {
+		if len(missingServices(grant.Scopes, []string{service})) == 0 {
+			granted = append(granted, service)
+		}
+	}
+	expiry := ""
+	if !grant.Token.Expiry.IsZero() {
+		expiry = grant.Token.Expiry.Format(time.RFC3339)
+	}
+
+	return &pb.GetAuthStatusResponse{
+		Common:            &pb.CommonResponse{Status: "OK", Message: "Auth status retrieved successfully."},
+		Connected:         true,
+		Email:             grant.Email,
+		GrantedServices:   granted,
+		Scopes:            grant.Scopes,
+		AccessTokenExpiry: expiry,
+	}, nil
+}
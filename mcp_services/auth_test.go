@@ -0,0 +1,61 @@
+// mcp_services/auth_test.go
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestConfirmAuthorization(t *testing.T) {
+	f, err := newAuthorizationFlows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"email":"ana@example.com"}`))
+	tok := (&oauth2.Token{AccessToken: "ya29.token"}).WithExtra(map[string]interface{}{"id_token": "header." + claims + ".signature"})
+	code, err := f.Hold("attacker", tok)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	// The code only works for the user the link was issued for.
+	if _, err := f.Confirm("victim", code); !errors.Is(err, errNoHeldAuthorization) {
+		t.Errorf("Confirm for another user = %v, want errNoHeldAuthorization", err)
+	}
+	if _, err := f.Confirm("attacker", wrong); !errors.Is(err, errWrongConfirmationCode) {
+		t.Errorf("Confirm with a wrong code = %v, want errWrongConfirmationCode", err)
+	}
+	held, err := f.Confirm("attacker", code)
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if held.token.AccessToken != "ya29.token" || held.email != "ana@example.com" {
+		t.Errorf("Confirm = %q, %q, want the held token and its account", held.token.AccessToken, held.email)
+	}
+	if _, err := f.Confirm("attacker", code); !errors.Is(err, errNoHeldAuthorization) {
+		t.Errorf("second Confirm = %v, want errNoHeldAuthorization", err)
+	}
+
+	// Too many wrong codes drop the authorization.
+	code, err = f.Hold("u1", tok)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	wrong = "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < maxConfirmationAttempts; i++ {
+		f.Confirm("u1", wrong)
+	}
+	if _, err := f.Confirm("u1", code); !errors.Is(err, errNoHeldAuthorization) {
+		t.Errorf("Confirm after %d wrong codes = %v, want errNoHeldAuthorization", maxConfirmationAttempts, err)
+	}
+}
@@ -0,0 +1,418 @@
+// mcp_services/contacts_duplicates.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Minimum similarity (0 to 1) for two names to be considered the same person
+	minNameSimilarity = 0.85
+	// Names shorter than this (after normalization) are never matched fuzzily
+	minFuzzyNameLength = 4
+	// Minimum number of digits for a phone number to be compared
+	minPhoneMatchDigits = 7
+	// Number of trailing digits compared, so numbers that cannot be
+	// normalized still match their E.164 form
+	phoneMatchSuffixDigits = 9
+	// Page size used when reading every contact
+	connectionsPageSize = 1000
+)
+
+// Reasons reported in pb.DuplicateCluster.Reasons
+const (
+	duplicateReasonEmail = "EMAIL"
+	duplicateReasonPhone = "PHONE"
+	duplicateReasonName  = "NAME"
+)
+
+// emailMatchKey normalizes an email address for comparison.
+func emailMatchKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// phoneMatchKey reduces a phone number to the trailing digits of its E.164
+// form, or "" if it is too short to be compared reliably. Numbers that cannot
+// be normalized are compared by their raw digits.
+func phoneMatchKey(p *people.PhoneNumber) string {
+	phone := storedPhoneE164(p)
+	if phone == "" {
+		phone = p.Value
+	}
+	digits := digitsOnly(phone)
+	if len(digits) < minPhoneMatchDigits {
+		return ""
+	}
+	if len(digits) > phoneMatchSuffixDigits {
+		digits = digits[len(digits)-phoneMatchSuffixDigits:]
+	}
+	return digits
+}
+
+// nameMatchKey normalizes a name for fuzzy comparison: accents and
+// punctuation are dropped and the words sorted, so "Doe, Joe" equals "Joe Doe".
+func nameMatchKey(name string) string {
+	words := strings.FieldsFunc(normalizeForSearch(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// nameSimilarity returns 1 minus the Levenshtein distance between two name
+// keys divided by the length of the longer one.
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// namesMatch reports whether two name keys are similar enough to belong to
+// the same person.
+func namesMatch(a, b string) bool {
+	if len([]rune(a)) < minFuzzyNameLength || len([]rune(b)) < minFuzzyNameLength {
+		return false
+	}
+	return a == b || nameSimilarity(a, b) >= minNameSimilarity
+}
+
+func personNameKey(p *people.Person) string {
+	if len(p.Names) == 0 {
+		return ""
+	}
+	name := p.Names[0].DisplayName
+	if name == "" {
+		name = p.Names[0].UnstructuredName
+	}
+	return nameMatchKey(name)
+}
+
+// duplicateReasons lists why two contacts look like the same person, or
+// returns nil if they do not.
+func duplicateReasons(a, b *people.Person) []string {
+	var reasons []string
+
+	emails := make(map[string]bool)
+	for _, e := range a.EmailAddresses {
+		emails[emailMatchKey(e.Value)] = true
+	}
+	for _, e := range b.EmailAddresses {
+		if emails[emailMatchKey(e.Value)] {
+			reasons = append(reasons, duplicateReasonEmail)
+			break
+		}
+	}
+
+	phones := make(map[string]bool)
+	for _, p := range a.PhoneNumbers {
+		if key := phoneMatchKey(p); key != "" {
+			phones[key] = true
+		}
+	}
+	for _, p := range b.PhoneNumbers {
+		if phones[phoneMatchKey(p)] {
+			reasons = append(reasons, duplicateReasonPhone)
+			break
+		}
+	}
+
+	if namesMatch(personNameKey(a), personNameKey(b)) {
+		reasons = append(reasons, duplicateReasonName)
+	}
+	return reasons
+}
+
+// clusterDuplicates groups contacts that share an email address or phone
+// number or have similar names. Contacts are linked transitively, so A and C
+// end up together when both resemble B. Only clusters of two or more
+// contacts are returned, largest first.
+func clusterDuplicates(contacts []*people.Person) []*pb.DuplicateCluster {
+	parent := make([]int, len(contacts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	reasons := make(map[[2]int]map[string]bool) // Keyed by (i, j) with i < j
+	link := func(i, j int, reason string) {
+		if i == j {
+			return
+		}
+		if i > j {
+			i, j = j, i
+		}
+		parent[find(j)] = find(i)
+		if reasons[[2]int{i, j}] == nil {
+			reasons[[2]int{i, j}] = make(map[string]bool)
+		}
+		reasons[[2]int{i, j}][reason] = true
+	}
+
+	// Emails and phones are grouped by key; names need a pairwise comparison.
+	byEmail := make(map[string]int)
+	byPhone := make(map[string]int)
+	nameKeys := make([]string, len(contacts))
+	for i, c := range contacts {
+		for _, e := range c.EmailAddresses {
+			key := emailMatchKey(e.Value)
+			if key == "" {
+				continue
+			}
+			if first, ok := byEmail[key]; ok {
+				link(first, i, duplicateReasonEmail)
+			} else {
+				byEmail[key] = i
+			}
+		}
+		for _, p := range c.PhoneNumbers {
+			key := phoneMatchKey(p)
+			if key == "" {
+				continue
+			}
+			if first, ok := byPhone[key]; ok {
+				link(first, i, duplicateReasonPhone)
+			} else {
+				byPhone[key] = i
+			}
+		}
+		nameKeys[i] = personNameKey(c)
+	}
+	for i := range contacts {
+		for j := i + 1; j < len(contacts); j++ {
+			if namesMatch(nameKeys[i], nameKeys[j]) {
+				link(i, j, duplicateReasonName)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range contacts {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	clusterReasons := make(map[int]map[string]bool)
+	for pair, rs := range reasons {
+		root := find(pair[0])
+		if clusterReasons[root] == nil {
+			clusterReasons[root] = make(map[string]bool)
+		}
+		for r := range rs {
+			clusterReasons[root][r] = true
+		}
+	}
+
+	var clusters []*pb.DuplicateCluster
+	for root, idx := range members {
+		if len(idx) < 2 {
+			continue
+		}
+		cluster := &pb.DuplicateCluster{}
+		for _, i := range idx {
+			cluster.Contacts = append(cluster.Contacts, personToProto(contacts[i]))
+		}
+		for _, r := range []string{duplicateReasonEmail, duplicateReasonPhone, duplicateReasonName} {
+			if clusterReasons[root][r] {
+				cluster.Reasons = append(cluster.Reasons, r)
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Contacts) != len(clusters[j].Contacts) {
+			return len(clusters[i].Contacts) > len(clusters[j].Contacts)
+		}
+		return clusters[i].Contacts[0].ResourceName < clusters[j].Contacts[0].ResourceName
+	})
+	return clusters
+}
+
+// findPossibleDuplicates returns existing contacts that look like the given
+// new contact, searching by its name, emails and phone numbers.
+func findPossibleDuplicates(ctx context.Context, srv *people.Service, person *people.Person) ([]*people.Person, error) {
+	var queries []string
+	if len(person.Names) > 0 && person.Names[0].UnstructuredName != "" {
+		queries = append(queries, person.Names[0].UnstructuredName)
+	}
+	for _, e := range person.EmailAddresses {
+		queries = append(queries, e.Value)
+	}
+	for _, p := range person.PhoneNumbers {
+		if key := phoneMatchKey(p); key != "" {
+			queries = append(queries, key)
+		}
+	}
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	if err := warmUpContactSearch(ctx, srv); err != nil {
+		return nil, err
+	}
+
+	var duplicates []*people.Person
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		resp, err := srv.People.SearchContacts().Query(q).ReadMask(personReadFields).PageSize(maxSearchResults).Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resp.Results {
+			if r.Person == nil || seen[r.Person.ResourceName] {
+				continue
+			}
+			seen[r.Person.ResourceName] = true
+			if len(duplicateReasons(person, r.Person)) > 0 {
+				duplicates = append(duplicates, r.Person)
+			}
+		}
+	}
+	return duplicates, nil
+}
+
+func (s *contactsServer) FindDuplicateContacts(ctx context.Context, req *pb.FindDuplicateContactsRequest) (*pb.FindDuplicateContactsResponse, error) {
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	var contacts []*people.Person
+	err = srv.People.Connections.List("people/me").
+		PersonFields(personReadFields).
+		PageSize(connectionsPageSize).
+		Pages(ctx, func(page *people.ListConnectionsResponse) error {
+			contacts = append(contacts, page.Connections...)
+			return nil
+		})
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list connections")
+	}
+
+	clusters := clusterDuplicates(contacts)
+	if req.MaxClusters > 0 && len(clusters) > int(req.MaxClusters) {
+		clusters = clusters[:req.MaxClusters]
+	}
+
+	return &pb.FindDuplicateContactsResponse{
+		Common:   &pb.CommonResponse{Status: "OK", Message: fmt.Sprintf("Found %d groups of possible duplicates.", len(clusters))},
+		Clusters: clusters,
+	}, nil
+}
+
+func (s *contactsServer) MergeContacts(ctx context.Context, req *pb.MergeContactsRequest) (*pb.MergeContactsResponse, error) {
+	if len(req.ResourceNames) < 2 {
+		return nil, status.Errorf(codes.InvalidArgument, "At least two contacts are required to merge.")
+	}
+	seen := make(map[string]bool)
+	for _, name := range req.ResourceNames {
+		if seen[name] {
+			return nil, status.Errorf(codes.InvalidArgument, "Contact %s is listed more than once.", name)
+		}
+		seen[name] = true
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	contacts, err := batchGetPeople(ctx, srv, req.ResourceNames, personReadFields+",memberships")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*people.Person)
+	for _, c := range contacts {
+		byName[c.ResourceName] = c
+	}
+	for _, name := range req.ResourceNames {
+		if byName[name] == nil {
+			return nil, status.Errorf(codes.NotFound, "Contact %s not found.", name)
+		}
+	}
+
+	kept := byName[req.ResourceNames[0]]
+	changed := make(map[string]bool)
+	var fields []string
+	for _, name := range req.ResourceNames[1:] {
+		for _, f := range mergePersonFields(kept, byName[name]) {
+			if !changed[f] {
+				changed[f] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// Update the kept contact first, so nothing is lost if the update fails.
+	merged := kept
+	if len(fields) > 0 {
+		merged, err = srv.People.UpdateContact(kept.ResourceName, kept).
+			UpdatePersonFields(strings.Join(fields, ",")).
+			PersonFields(personReadFields).
+			Context(ctx).
+			Do()
+		if err != nil {
+			if isEtagMismatch(err) {
+				return nil, status.Errorf(codes.Aborted, "Contact %s was modified during the merge; retry.", kept.ResourceName)
+			}
+			return nil, googleAPIError(err, "Unable to update merged contact")
+		}
+	}
+
+	deleted := req.ResourceNames[1:]
+	if _, err := srv.People.BatchDeleteContacts(&people.BatchDeleteContactsRequest{ResourceNames: deleted}).Context(ctx).Do(); err != nil {
+		return nil, googleAPIError(err, "Contact %s was updated but the duplicates could not be deleted", kept.ResourceName)
+	}
+
+	return &pb.MergeContactsResponse{
+		Common:               &pb.CommonResponse{Status: "OK", Message: "Contacts merged successfully."},
+		MergedContact:        personToProto(merged),
+		DeletedResourceNames: deleted,
+	}, nil
+}
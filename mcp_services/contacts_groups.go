@@ -0,0 +1,232 @@
+// mcp_services/contacts_groups.go
+package main
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Maximum number of people accepted by a single people.getBatchGet call
+	batchGetPeopleChunkSize = 200
+	// Page size used when listing contact groups
+	contactGroupsPageSize = 1000
+)
+
+func contactGroupToProto(g *people.ContactGroup) *pb.ContactGroup {
+	name := g.FormattedName
+	if name == "" {
+		name = g.Name
+	}
+	return &pb.ContactGroup{
+		ResourceName: g.ResourceName,
+		Name:         name,
+		MemberCount:  int32(g.MemberCount),
+		GroupType:    g.GroupType,
+	}
+}
+
+// listContactGroups returns every contact group of the user.
+func listContactGroups(ctx context.Context, srv *people.Service) ([]*people.ContactGroup, error) {
+	var groups []*people.ContactGroup
+	err := srv.ContactGroups.List().PageSize(contactGroupsPageSize).Pages(ctx, func(page *people.ListContactGroupsResponse) error {
+		groups = append(groups, page.ContactGroups...)
+		return nil
+	})
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list contact groups")
+	}
+	return groups, nil
+}
+
+// resolveContactGroup finds a contact group by resource name or, ignoring
+// case, by name, so users can refer to their "Clients" label directly.
+func resolveContactGroup(ctx context.Context, srv *people.Service, group string) (*people.ContactGroup, error) {
+	if group == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A contact group is required.")
+	}
+	groups, err := listContactGroups(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.ResourceName == group {
+			return g, nil
+		}
+	}
+	for _, g := range groups {
+		if strings.EqualFold(g.Name, group) || strings.EqualFold(g.FormattedName, group) {
+			return g, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "Contact group %q not found.", group)
+}
+
+// listGroupMembers returns up to limit members of a contact group with their
+// full records. A limit of 0 returns every member.
+func listGroupMembers(ctx context.Context, srv *people.Service, group *people.ContactGroup, limit int) ([]*people.Person, error) {
+	maxMembers := group.MemberCount
+	if limit > 0 && int64(limit) < maxMembers {
+		maxMembers = int64(limit)
+	}
+	if maxMembers == 0 {
+		return nil, nil
+	}
+
+	g, err := srv.ContactGroups.Get(group.ResourceName).MaxMembers(maxMembers).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get contact group members")
+	}
+
+	return batchGetPeople(ctx, srv, g.MemberResourceNames, personReadFields)
+}
+
+// batchGetPeople reads the given person fields of the contacts, skipping
+// those that no longer exist.
+func batchGetPeople(ctx context.Context, srv *people.Service, resourceNames []string, personFields string) ([]*people.Person, error) {
+	var found []*people.Person
+	for start := 0; start < len(resourceNames); start += batchGetPeopleChunkSize {
+		end := start + batchGetPeopleChunkSize
+		if end > len(resourceNames) {
+			end = len(resourceNames)
+		}
+		resp, err := srv.People.GetBatchGet().ResourceNames(resourceNames[start:end]...).PersonFields(personFields).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to get contacts")
+		}
+		for _, r := range resp.Responses {
+			if r.Person != nil {
+				found = append(found, r.Person)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *contactsServer) ListContactGroups(ctx context.Context, req *pb.ListContactGroupsRequest) (*pb.ListContactGroupsResponse, error) {
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	groups, err := listContactGroups(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	var pbGroups []*pb.ContactGroup
+	for _, g := range groups {
+		pbGroups = append(pbGroups, contactGroupToProto(g))
+	}
+
+	return &pb.ListContactGroupsResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Contact groups listed successfully."},
+		Groups: pbGroups,
+	}, nil
+}
+
+func (s *contactsServer) CreateContactGroup(ctx context.Context, req *pb.CreateContactGroupRequest) (*pb.CreateContactGroupResponse, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A group name is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	group, err := srv.ContactGroups.Create(&people.CreateContactGroupRequest{
+		ContactGroup: &people.ContactGroup{Name: req.Name},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to create contact group")
+	}
+
+	return &pb.CreateContactGroupResponse{
+		Common:       &pb.CommonResponse{Status: "OK", Message: "Contact group created successfully."},
+		CreatedGroup: contactGroupToProto(group),
+	}, nil
+}
+
+func (s *contactsServer) AddContactsToGroup(ctx context.Context, req *pb.AddContactsToGroupRequest) (*pb.AddContactsToGroupResponse, error) {
+	if len(req.ResourceNames) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "At least one contact is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	group, err := resolveContactGroup(ctx, srv, req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := srv.ContactGroups.Members.Modify(group.ResourceName, &people.ModifyContactGroupMembersRequest{
+		ResourceNamesToAdd: req.ResourceNames,
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to add contacts to group")
+	}
+
+	return &pb.AddContactsToGroupResponse{
+		Common:                &pb.CommonResponse{Status: "OK", Message: "Contacts added to group successfully."},
+		NotFoundResourceNames: resp.NotFoundResourceNames,
+	}, nil
+}
+
+func (s *contactsServer) RemoveContactsFromGroup(ctx context.Context, req *pb.RemoveContactsFromGroupRequest) (*pb.RemoveContactsFromGroupResponse, error) {
+	if len(req.ResourceNames) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "At least one contact is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	group, err := resolveContactGroup(ctx, srv, req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := srv.ContactGroups.Members.Modify(group.ResourceName, &people.ModifyContactGroupMembersRequest{
+		ResourceNamesToRemove: req.ResourceNames,
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to remove contacts from group")
+	}
+
+	return &pb.RemoveContactsFromGroupResponse{
+		Common:                  &pb.CommonResponse{Status: "OK", Message: "Contacts removed from group successfully."},
+		NotFoundResourceNames:   resp.NotFoundResourceNames,
+		NotRemovedResourceNames: resp.CanNotRemoveLastContactGroupResourceNames,
+	}, nil
+}
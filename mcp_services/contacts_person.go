@@ -0,0 +1,284 @@
+// mcp_services/contacts_person.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/people/v1"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Person fields requested whenever a full contact is read
+const personReadFields = "names,emailAddresses,phoneNumbers,organizations,birthdays,addresses,biographies,photos"
+
+// formatBirthday renders a People API date as "YYYY-MM-DD", or "--MM-DD"
+// when the year is unknown (the vCard convention).
+func formatBirthday(b *people.Birthday) string {
+	if b.Date == nil || b.Date.Month == 0 || b.Date.Day == 0 {
+		return b.Text
+	}
+	if b.Date.Year == 0 {
+		return fmt.Sprintf("--%02d-%02d", b.Date.Month, b.Date.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", b.Date.Year, b.Date.Month, b.Date.Day)
+}
+
+// parseBirthday is the inverse of formatBirthday.
+func parseBirthday(s string) (*people.Birthday, error) {
+	if len(s) == 7 && s[:2] == "--" {
+		t, err := time.Parse("01-02", s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid birthday %q: %w", s, err)
+		}
+		return &people.Birthday{Date: &people.Date{Month: int64(t.Month()), Day: int64(t.Day())}}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid birthday %q, expected YYYY-MM-DD or --MM-DD", s)
+	}
+	return &people.Birthday{Date: &people.Date{Year: int64(t.Year()), Month: int64(t.Month()), Day: int64(t.Day())}}, nil
+}
+
+// personToProto converts a People API person to a pb.Person. The singular
+// email and phone_number fields hold the first entry of each list.
+func personToProto(person *people.Person) *pb.Person {
+	pbPerson := &pb.Person{
+		ResourceName: person.ResourceName,
+		Etag:         person.Etag,
+	}
+	if len(person.Names) > 0 {
+		pbPerson.DisplayName = person.Names[0].DisplayName
+	}
+	for _, e := range person.EmailAddresses {
+		pbPerson.Emails = append(pbPerson.Emails, &pb.ContactEmail{Value: e.Value, Type: e.Type})
+	}
+	if len(pbPerson.Emails) > 0 {
+		pbPerson.Email = pbPerson.Emails[0].Value
+	}
+	for _, p := range person.PhoneNumbers {
+		pbPerson.Phones = append(pbPerson.Phones, &pb.ContactPhone{Value: p.Value, Type: p.Type, Normalized: storedPhoneE164(p)})
+	}
+	if len(pbPerson.Phones) > 0 {
+		pbPerson.PhoneNumber = pbPerson.Phones[0].Value
+		pbPerson.NormalizedPhoneNumber = pbPerson.Phones[0].Normalized
+	}
+	for _, o := range person.Organizations {
+		pbPerson.Organizations = append(pbPerson.Organizations, &pb.Organization{Name: o.Name, Title: o.Title, Department: o.Department})
+	}
+	if len(person.Birthdays) > 0 {
+		pbPerson.Birthday = formatBirthday(person.Birthdays[0])
+	}
+	for _, a := range person.Addresses {
+		pbPerson.Addresses = append(pbPerson.Addresses, &pb.PostalAddress{
+			Formatted:  a.FormattedValue,
+			Street:     a.StreetAddress,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+			Type:       a.Type,
+		})
+	}
+	if len(person.Biographies) > 0 {
+		pbPerson.Notes = person.Biographies[0].Value
+	}
+	for _, p := range person.Photos {
+		// Skip the generated placeholder avatar.
+		if !p.Default {
+			pbPerson.PhotoUrl = p.Url
+			break
+		}
+	}
+	return pbPerson
+}
+
+// personFromProto builds a People API person from the writable fields of a pb.Person.
+func personFromProto(p *pb.Person) (*people.Person, error) {
+	person := &people.Person{
+		ResourceName: p.ResourceName,
+		Etag:         p.Etag,
+	}
+	if p.DisplayName != "" {
+		person.Names = []*people.Name{{UnstructuredName: p.DisplayName}}
+	}
+	emails := p.Emails
+	if len(emails) == 0 && p.Email != "" {
+		emails = []*pb.ContactEmail{{Value: p.Email}}
+	}
+	for _, e := range emails {
+		person.EmailAddresses = append(person.EmailAddresses, &people.EmailAddress{Value: e.Value, Type: e.Type})
+	}
+	phones := p.Phones
+	if len(phones) == 0 && p.PhoneNumber != "" {
+		phones = []*pb.ContactPhone{{Value: p.PhoneNumber}}
+	}
+	for _, ph := range phones {
+		person.PhoneNumbers = append(person.PhoneNumbers, &people.PhoneNumber{Value: ph.Value, Type: ph.Type})
+	}
+	for _, o := range p.Organizations {
+		person.Organizations = append(person.Organizations, &people.Organization{Name: o.Name, Title: o.Title, Department: o.Department})
+	}
+	if p.Birthday != "" {
+		b, err := parseBirthday(p.Birthday)
+		if err != nil {
+			return nil, err
+		}
+		person.Birthdays = []*people.Birthday{b}
+	}
+	for _, a := range p.Addresses {
+		person.Addresses = append(person.Addresses, &people.Address{
+			FormattedValue: a.Formatted,
+			StreetAddress:  a.Street,
+			City:           a.City,
+			Region:         a.Region,
+			PostalCode:     a.PostalCode,
+			Country:        a.Country,
+			Type:           a.Type,
+		})
+	}
+	if p.Notes != "" {
+		person.Biographies = []*people.Biography{{Value: p.Notes, ContentType: "TEXT_PLAIN"}}
+	}
+	return person, nil
+}
+
+// mergePersonFields adds the fields of src to an existing contact and
+// returns the person fields that changed. Emails, phones, addresses and group
+// memberships not yet present are appended; the other fields are only filled
+// in when the contact has none, so a merge never overwrites data.
+func mergePersonFields(existing, src *people.Person) []string {
+	var fields []string
+
+	if len(existing.Names) == 0 && len(src.Names) > 0 {
+		existing.Names = src.Names
+		fields = append(fields, "names")
+	}
+
+	addedEmail := false
+	for _, e := range src.EmailAddresses {
+		found := false
+		for _, cur := range existing.EmailAddresses {
+			if strings.EqualFold(cur.Value, e.Value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing.EmailAddresses = append(existing.EmailAddresses, e)
+			addedEmail = true
+		}
+	}
+	if addedEmail {
+		fields = append(fields, "emailAddresses")
+	}
+
+	addedPhone := false
+	for _, p := range src.PhoneNumbers {
+		found := false
+		for _, cur := range existing.PhoneNumbers {
+			if digitsOnly(cur.Value) == digitsOnly(p.Value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing.PhoneNumbers = append(existing.PhoneNumbers, p)
+			addedPhone = true
+		}
+	}
+	if addedPhone {
+		fields = append(fields, "phoneNumbers")
+	}
+
+	addedAddress := false
+	for _, a := range src.Addresses {
+		found := false
+		for _, cur := range existing.Addresses {
+			if strings.EqualFold(cur.FormattedValue, a.FormattedValue) && strings.EqualFold(cur.StreetAddress, a.StreetAddress) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing.Addresses = append(existing.Addresses, a)
+			addedAddress = true
+		}
+	}
+	if addedAddress {
+		fields = append(fields, "addresses")
+	}
+
+	addedMembership := false
+	for _, m := range src.Memberships {
+		if m.ContactGroupMembership == nil {
+			continue
+		}
+		found := false
+		for _, cur := range existing.Memberships {
+			if cur.ContactGroupMembership != nil &&
+				cur.ContactGroupMembership.ContactGroupResourceName == m.ContactGroupMembership.ContactGroupResourceName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing.Memberships = append(existing.Memberships, &people.Membership{
+				ContactGroupMembership: &people.ContactGroupMembership{ContactGroupResourceName: m.ContactGroupMembership.ContactGroupResourceName},
+			})
+			addedMembership = true
+		}
+	}
+	if addedMembership {
+		fields = append(fields, "memberships")
+	}
+
+	if len(existing.Organizations) == 0 && len(src.Organizations) > 0 {
+		existing.Organizations = src.Organizations
+		fields = append(fields, "organizations")
+	}
+	if len(existing.Birthdays) == 0 && len(src.Birthdays) > 0 {
+		existing.Birthdays = src.Birthdays
+		fields = append(fields, "birthdays")
+	}
+	if len(existing.Biographies) == 0 && len(src.Biographies) > 0 {
+		existing.Biographies = src.Biographies
+		fields = append(fields, "biographies")
+	}
+	return fields
+}
+
+// digitsOnly strips everything but digits, so formatting differences in
+// phone numbers do not count as different numbers.
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// createRequestToPerson collects the fields of a CreateContactRequest into a
+// pb.Person. The email and phone_number shorthands come first.
+func createRequestToPerson(req *pb.CreateContactRequest) *pb.Person {
+	p := &pb.Person{
+		DisplayName:   req.DisplayName,
+		Organizations: req.Organizations,
+		Birthday:      req.Birthday,
+		Addresses:     req.Addresses,
+		Notes:         req.Notes,
+	}
+	if req.Email != "" {
+		p.Emails = append(p.Emails, &pb.ContactEmail{Value: req.Email})
+	}
+	p.Emails = append(p.Emails, req.Emails...)
+	if req.PhoneNumber != "" {
+		p.Phones = append(p.Phones, &pb.ContactPhone{Value: req.PhoneNumber})
+	}
+	p.Phones = append(p.Phones, req.Phones...)
+	return p
+}
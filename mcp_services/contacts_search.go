@@ -0,0 +1,224 @@
+// mcp_services/contacts_search.go
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Default and maximum number of matches returned by SearchContacts
+	defaultSearchResults = 10
+	maxSearchResults     = 30
+	// Fields requested for other contacts, which only support a few
+	otherContactsReadMask = "names,emailAddresses,phoneNumbers"
+)
+
+// warmUpContactSearch sends the empty queries the People API asks for before
+// searching contacts and other contacts, so its search cache is current.
+func warmUpContactSearch(ctx context.Context, srv *people.Service) error {
+	if _, err := srv.People.SearchContacts().Query("").ReadMask(personReadFields).Context(ctx).Do(); err != nil {
+		return err
+	}
+	if _, err := srv.OtherContacts.Search().Query("").ReadMask(otherContactsReadMask).Context(ctx).Do(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Contact match sources, as exposed in pb.ContactMatch.Source
+const (
+	matchSourceContact      = "CONTACT"
+	matchSourceOtherContact = "OTHER_CONTACT"
+)
+
+// accentFolder strips the accents most common in our users' names, so that
+// "Maria" finds "María".
+var accentFolder = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "â", "a", "ê", "e",
+	"î", "i", "ô", "o", "û", "u", "ã", "a", "õ", "o", "ç", "c",
+)
+
+func normalizeForSearch(s string) string {
+	return accentFolder.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// scoreContactMatch rates how well a person matches a search query. Exact
+// name and address matches rank above prefixes, which rank above substrings.
+// It returns 0 if the person does not match at all.
+func scoreContactMatch(query, name string, emails []string) int {
+	q := normalizeForSearch(query)
+	if q == "" {
+		return 0
+	}
+
+	best := 0
+	consider := func(score int) {
+		if score > best {
+			best = score
+		}
+	}
+
+	n := normalizeForSearch(name)
+	switch {
+	case n == q:
+		consider(100)
+	case strings.HasPrefix(n, q):
+		consider(80)
+	case strings.Contains(n, q):
+		consider(40)
+	}
+	for _, word := range strings.Fields(n) {
+		switch {
+		case word == q:
+			consider(70)
+		case strings.HasPrefix(word, q):
+			consider(60)
+		}
+	}
+
+	for _, email := range emails {
+		e := normalizeForSearch(email)
+		local := e
+		if at := strings.Index(e, "@"); at >= 0 {
+			local = e[:at]
+		}
+		switch {
+		case e == q:
+			consider(100)
+		case local == q:
+			consider(90)
+		case strings.HasPrefix(e, q):
+			consider(50)
+		case strings.Contains(e, q):
+			consider(30)
+		}
+	}
+	return best
+}
+
+// contactMatchFromPerson converts a search result, scoring it against query.
+func contactMatchFromPerson(query string, person *people.Person, source string) *pb.ContactMatch {
+	match := &pb.ContactMatch{
+		ResourceName: person.ResourceName,
+		Source:       source,
+		Etag:         person.Etag,
+		Person:       personToProto(person),
+	}
+	if len(person.Names) > 0 {
+		match.DisplayName = person.Names[0].DisplayName
+	}
+	for _, e := range person.EmailAddresses {
+		match.Emails = append(match.Emails, e.Value)
+	}
+	for _, p := range person.PhoneNumbers {
+		match.PhoneNumbers = append(match.PhoneNumbers, p.Value)
+	}
+
+	match.Score = int32(scoreContactMatch(query, match.DisplayName, match.Emails))
+	// Google also matches on fields we don't score, such as phone numbers; keep those results.
+	if match.Score == 0 {
+		match.Score = 10
+	}
+	// Prefer saved contacts over people the user merely exchanged emails with.
+	if source == matchSourceContact {
+		match.Score += 5
+	}
+	return match
+}
+
+// rankContactMatches sorts matches best first and drops other contacts whose
+// addresses already belong to a saved contact.
+func rankContactMatches(matches []*pb.ContactMatch, limit int) []*pb.ContactMatch {
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+
+	seenEmails := make(map[string]bool)
+	for _, m := range matches {
+		if m.Source == matchSourceContact {
+			for _, e := range m.Emails {
+				seenEmails[strings.ToLower(e)] = true
+			}
+		}
+	}
+
+	var ranked []*pb.ContactMatch
+	for _, m := range matches {
+		if m.Source == matchSourceOtherContact {
+			duplicate := false
+			for _, e := range m.Emails {
+				if seenEmails[strings.ToLower(e)] {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				continue
+			}
+			for _, e := range m.Emails {
+				seenEmails[strings.ToLower(e)] = true
+			}
+		}
+		ranked = append(ranked, m)
+		if len(ranked) == limit {
+			break
+		}
+	}
+	return ranked
+}
+
+func (s *contactsServer) SearchContacts(ctx context.Context, req *pb.SearchContactsRequest) (*pb.SearchContactsResponse, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A search query is required.")
+	}
+	limit := int(req.MaxResults)
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	if limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	if err := warmUpContactSearch(ctx, srv); err != nil {
+		return nil, googleAPIError(err, "Unable to prepare contact search")
+	}
+
+	var matches []*pb.ContactMatch
+	contacts, err := srv.People.SearchContacts().Query(req.Query).ReadMask(personReadFields).PageSize(maxSearchResults).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to search contacts")
+	}
+	for _, r := range contacts.Results {
+		matches = append(matches, contactMatchFromPerson(req.Query, r.Person, matchSourceContact))
+	}
+
+	others, err := srv.OtherContacts.Search().Query(req.Query).ReadMask(otherContactsReadMask).PageSize(maxSearchResults).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to search other contacts")
+	}
+	for _, r := range others.Results {
+		matches = append(matches, contactMatchFromPerson(req.Query, r.Person, matchSourceOtherContact))
+	}
+
+	return &pb.SearchContactsResponse{
+		Common:  &pb.CommonResponse{Status: "OK", Message: "Contacts searched successfully."},
+		Matches: rankContactMatches(matches, limit),
+	}, nil
+}
@@ -0,0 +1,246 @@
+// mcp_services/contacts_update.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// contactUpdateFields maps the update_mask names of UpdateContactRequest to
+// People API person fields.
+var contactUpdateFields = map[string]string{
+	"display_name": "names",
+	"email":        "emailAddresses",
+	"phone_number": "phoneNumbers",
+}
+
+// primaryIndex returns the index of the entry of a People API list marked as
+// primary, or 0 if none is.
+func primaryIndex(n int, metadata func(int) *people.FieldMetadata) int {
+	for i := 0; i < n; i++ {
+		if m := metadata(i); m != nil && m.Primary {
+			return i
+		}
+	}
+	return 0
+}
+
+// updateEmailAddresses sets value as the primary address of a contact,
+// keeping its other addresses. If value is already one of them the list is
+// unchanged; an empty value removes the primary address.
+func updateEmailAddresses(emails []*people.EmailAddress, value string) []*people.EmailAddress {
+	for _, e := range emails {
+		if value != "" && strings.EqualFold(e.Value, value) {
+			return emails
+		}
+	}
+	if len(emails) == 0 {
+		if value == "" {
+			return nil
+		}
+		return []*people.EmailAddress{{Value: value}}
+	}
+	i := primaryIndex(len(emails), func(i int) *people.FieldMetadata { return emails[i].Metadata })
+	if value == "" {
+		return append(emails[:i:i], emails[i+1:]...)
+	}
+	emails[i].Value = value
+	return emails
+}
+
+// updatePhoneNumbers sets value as the primary phone number of a contact,
+// the same way updateEmailAddresses does for addresses.
+func updatePhoneNumbers(phones []*people.PhoneNumber, value string) []*people.PhoneNumber {
+	for _, p := range phones {
+		if value != "" && p.Value == value {
+			return phones
+		}
+	}
+	if len(phones) == 0 {
+		if value == "" {
+			return nil
+		}
+		return []*people.PhoneNumber{{Value: value}}
+	}
+	i := primaryIndex(len(phones), func(i int) *people.FieldMetadata { return phones[i].Metadata })
+	if value == "" {
+		return append(phones[:i:i], phones[i+1:]...)
+	}
+	phones[i].Value = value
+	return phones
+}
+
+// isEtagMismatch reports whether a People API error was caused by a stale
+// etag. The API answers those with HTTP 400 and a FAILED_PRECONDITION status.
+func isEtagMismatch(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	if gerr.Code != http.StatusBadRequest && gerr.Code != http.StatusPreconditionFailed {
+		return false
+	}
+	for _, item := range gerr.Errors {
+		if item.Reason == "failedPrecondition" {
+			return true
+		}
+	}
+	return strings.Contains(gerr.Body, "FAILED_PRECONDITION")
+}
+
+func (s *contactsServer) UpdateContact(ctx context.Context, req *pb.UpdateContactRequest) (*pb.UpdateContactResponse, error) {
+	if req.ResourceName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A contact resource name is required.")
+	}
+	if req.Etag == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "The contact etag is required to update it.")
+	}
+
+	mask := req.UpdateMask
+	if len(mask) == 0 {
+		if req.DisplayName != "" {
+			mask = append(mask, "display_name")
+		}
+		if req.Email != "" {
+			mask = append(mask, "email")
+		}
+		if req.PhoneNumber != "" {
+			mask = append(mask, "phone_number")
+		}
+	}
+	if len(mask) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Nothing to update.")
+	}
+
+	var personFields []string
+	var phone string
+	for _, field := range mask {
+		personField, ok := contactUpdateFields[field]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown update_mask field %q.", field)
+		}
+		switch field {
+		case "display_name":
+			if req.DisplayName == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "A contact's display name cannot be cleared.")
+			}
+		case "phone_number":
+			if req.PhoneNumber != "" {
+				var err error
+				if phone, err = normalizePhoneNumber(req.PhoneNumber, requestPhoneRegion(req.Common)); err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "Invalid contact: %v", err)
+				}
+			}
+		}
+		personFields = append(personFields, personField)
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	// Emails and phone numbers are lists, of which a pb.Person only carries
+	// the primary entry, so start from the current ones to keep the others.
+	// The etag sent with the update still guards against concurrent edits.
+	contact := &people.Person{
+		ResourceName: req.ResourceName,
+		Etag:         req.Etag,
+	}
+	var listFields []string
+	for _, field := range mask {
+		if field == "email" || field == "phone_number" {
+			listFields = append(listFields, contactUpdateFields[field])
+		}
+	}
+	if len(listFields) > 0 {
+		current, err := srv.People.Get(req.ResourceName).PersonFields(strings.Join(listFields, ",")).Context(ctx).Do()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Unable to get contact: %v", err)
+		}
+		contact.EmailAddresses = current.EmailAddresses
+		contact.PhoneNumbers = current.PhoneNumbers
+	}
+
+	// A field in the mask with an empty value clears it; for emails and
+	// phone numbers only the primary entry is cleared.
+	for _, field := range mask {
+		switch field {
+		case "display_name":
+			contact.Names = []*people.Name{{UnstructuredName: req.DisplayName}}
+		case "email":
+			contact.EmailAddresses = updateEmailAddresses(contact.EmailAddresses, req.Email)
+		case "phone_number":
+			contact.PhoneNumbers = updatePhoneNumbers(contact.PhoneNumbers, phone)
+		}
+	}
+
+	updated, err := srv.People.UpdateContact(req.ResourceName, contact).
+		UpdatePersonFields(strings.Join(personFields, ",")).
+		PersonFields(personReadFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		if isEtagMismatch(err) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Contact %s was modified since it was read; fetch it again and retry.", req.ResourceName)
+		}
+		return nil, googleAPIError(err, "Unable to update contact")
+	}
+
+	return &pb.UpdateContactResponse{
+		Common:         &pb.CommonResponse{Status: "OK", Message: "Contact updated successfully."},
+		UpdatedContact: personToProto(updated),
+	}, nil
+}
+
+func (s *contactsServer) DeleteContact(ctx context.Context, req *pb.DeleteContactRequest) (*pb.DeleteContactResponse, error) {
+	if req.ResourceName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A contact resource name is required.")
+	}
+	if req.Etag == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "The contact etag is required to delete it.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	// people.deleteContact takes no etag, so compare it against the current
+	// version first. Etags depend on the fields requested, so use the same
+	// ones as the responses the client got the etag from.
+	current, err := srv.People.Get(req.ResourceName).PersonFields(personReadFields).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get contact")
+	}
+	if current.Etag != req.Etag {
+		return nil, status.Errorf(codes.FailedPrecondition, "Contact %s was modified since it was read; fetch it again and retry.", req.ResourceName)
+	}
+
+	if _, err := srv.People.DeleteContact(req.ResourceName).Context(ctx).Do(); err != nil {
+		return nil, googleAPIError(err, "Unable to delete contact")
+	}
+
+	return &pb.DeleteContactResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Contact deleted successfully."},
+	}, nil
+}
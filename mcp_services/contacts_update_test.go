@@ -0,0 +1,54 @@
+// mcp_services/contacts_update_test.go
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/people/v1"
+)
+
+func TestUpdateEmailAddresses(t *testing.T) {
+	primary := &people.FieldMetadata{Primary: true}
+	tests := []struct {
+		name   string
+		emails []*people.EmailAddress
+		value  string
+		want   []string
+	}{
+		{"no emails", nil, "ana@example.com", []string{"ana@example.com"}},
+		{"replaces the first without a primary",
+			[]*people.EmailAddress{{Value: "old@example.com"}, {Value: "work@example.com"}},
+			"new@example.com", []string{"new@example.com", "work@example.com"}},
+		{"replaces the primary",
+			[]*people.EmailAddress{{Value: "work@example.com"}, {Value: "old@example.com", Metadata: primary}},
+			"new@example.com", []string{"work@example.com", "new@example.com"}},
+		{"keeps an existing address",
+			[]*people.EmailAddress{{Value: "old@example.com"}, {Value: "Work@example.com"}},
+			"work@example.com", []string{"old@example.com", "Work@example.com"}},
+		{"clears only the primary",
+			[]*people.EmailAddress{{Value: "work@example.com"}, {Value: "old@example.com", Metadata: primary}},
+			"", []string{"work@example.com"}},
+		{"clearing nothing", nil, "", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range updateEmailAddresses(tt.emails, tt.value) {
+			got = append(got, e.Value)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdatePhoneNumbers(t *testing.T) {
+	phones := []*people.PhoneNumber{
+		{Value: "+34 600 000 001", Type: "mobile"},
+		{Value: "+34 910 000 002", Type: "work", Metadata: &people.FieldMetadata{Primary: true}},
+	}
+	got := updatePhoneNumbers(phones, "+34 910 000 003")
+	if len(got) != 2 || got[0].Value != "+34 600 000 001" || got[1].Value != "+34 910 000 003" || got[1].Type != "work" {
+		t.Errorf("updatePhoneNumbers replaced the wrong entry: %+v, %+v", got[0], got[1])
+	}
+}
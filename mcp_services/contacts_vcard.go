@@ -0,0 +1,198 @@
+// mcp_services/contacts_vcard.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Maximum number of vCards accepted by a single ImportVCard call
+	maxImportedVCards = 200
+
+	importActionCreated = "CREATED"
+	importActionUpdated = "UPDATED"
+	importActionFailed  = "FAILED"
+)
+
+// findContactByEmail returns the contact that has one of the given email
+// addresses, or nil if there is none.
+func findContactByEmail(ctx context.Context, srv *people.Service, emails []*people.EmailAddress) (*people.Person, error) {
+	for _, e := range emails {
+		resp, err := srv.People.SearchContacts().Query(e.Value).ReadMask(personReadFields).Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resp.Results {
+			if r.Person == nil {
+				continue
+			}
+			for _, existing := range r.Person.EmailAddresses {
+				if strings.EqualFold(existing.Value, e.Value) {
+					return r.Person, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// importPerson creates the contact, or merges it into the contact sharing
+// one of its email addresses when updateExisting is set. Phone numbers
+// without a country code are interpreted in phoneRegion.
+func importPerson(ctx context.Context, srv *people.Service, card *pb.Person, updateExisting bool, phoneRegion string) (*pb.ImportedContact, error) {
+	person, err := personFromProto(card)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizePersonPhones(person, phoneRegion); err != nil {
+		return nil, err
+	}
+
+	if updateExisting && len(person.EmailAddresses) > 0 {
+		existing, err := findContactByEmail(ctx, srv, person.EmailAddresses)
+		if err != nil {
+			return nil, fmt.Errorf("unable to look up existing contact: %w", err)
+		}
+		if existing != nil {
+			fields := mergePersonFields(existing, person)
+			if len(fields) == 0 {
+				return &pb.ImportedContact{Contact: personToProto(existing), Action: importActionUpdated}, nil
+			}
+			updated, err := srv.People.UpdateContact(existing.ResourceName, existing).
+				UpdatePersonFields(strings.Join(fields, ",")).
+				PersonFields(personReadFields).
+				Context(ctx).
+				Do()
+			if err != nil {
+				return nil, fmt.Errorf("unable to update contact: %w", err)
+			}
+			return &pb.ImportedContact{Contact: personToProto(updated), Action: importActionUpdated}, nil
+		}
+	}
+
+	created, err := srv.People.CreateContact(person).PersonFields(personReadFields).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create contact: %w", err)
+	}
+	return &pb.ImportedContact{Contact: personToProto(created), Action: importActionCreated}, nil
+}
+
+func (s *contactsServer) ImportVCard(ctx context.Context, req *pb.ImportVCardRequest) (*pb.ImportVCardResponse, error) {
+	cards, err := ParseVCards(req.VcardData)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid vCard data: %v", err)
+	}
+	if len(cards) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "No vCards found in the data.")
+	}
+	if len(cards) > maxImportedVCards {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d vCards can be imported at once, got %d.", maxImportedVCards, len(cards))
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	// Existing contacts are looked up with SearchContacts, whose cache must
+	// be current.
+	if req.UpdateExisting {
+		if err := warmUpContactSearch(ctx, srv); err != nil {
+			return nil, googleAPIError(err, "Unable to prepare contact search")
+		}
+	}
+
+	// A failing card does not stop the import; it is reported in its own entry.
+	var results []*pb.ImportedContact
+	failed := 0
+	for _, card := range cards {
+		result, err := importPerson(ctx, srv, card, req.UpdateExisting, requestPhoneRegion(req.Common))
+		if err != nil {
+			failed++
+			result = &pb.ImportedContact{Contact: card, Action: importActionFailed, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+
+	common := &pb.CommonResponse{Status: "OK", Message: "vCards imported successfully."}
+	if failed > 0 {
+		common.Message = fmt.Sprintf("Imported %d of %d vCards; see the failed entries.", len(cards)-failed, len(cards))
+	}
+	return &pb.ImportVCardResponse{
+		Common:   common,
+		Contacts: results,
+	}, nil
+}
+
+func (s *contactsServer) ExportVCard(ctx context.Context, req *pb.ExportVCardRequest) (*pb.ExportVCardResponse, error) {
+	if len(req.ResourceNames) == 0 && req.Group == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Either resource names or a contact group is required.")
+	}
+	if req.Version != "" && req.Version != vCardVersion3 && req.Version != vCardVersion4 {
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported vCard version %q, expected %s or %s.", req.Version, vCardVersion3, vCardVersion4)
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+
+	var contacts []*people.Person
+	if req.Group != "" {
+		group, err := resolveContactGroup(ctx, srv, req.Group)
+		if err != nil {
+			return nil, err
+		}
+		contacts, err = listGroupMembers(ctx, srv, group, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(req.ResourceNames) > 0 {
+		found, err := batchGetPeople(ctx, srv, req.ResourceNames, personReadFields)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, found...)
+	}
+
+	var b strings.Builder
+	seen := make(map[string]bool)
+	count := 0
+	for _, c := range contacts {
+		if seen[c.ResourceName] {
+			continue
+		}
+		seen[c.ResourceName] = true
+		card, err := FormatVCard(personToProto(c), req.Version)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Unable to format vCard: %v", err)
+		}
+		b.WriteString(card)
+		count++
+	}
+
+	return &pb.ExportVCardResponse{
+		Common:    &pb.CommonResponse{Status: "OK", Message: "Contacts exported successfully."},
+		VcardData: b.String(),
+		Count:     int32(count),
+	}, nil
+}
@@ -0,0 +1,184 @@
+// mcp_services/docs.go
+package main
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// ====================================================================
+// Docs Service Implementation
+// ====================================================================
+type docsServer struct {
+	pb.UnimplementedDocsServiceServer
+}
+
+func documentURL(documentID string) string {
+	return "https://docs.google.com/document/d/" + documentID + "/edit"
+}
+
+// appendTextRequest inserts text as a new paragraph at the end of the body.
+// endIndex is the end of the body; a body holding only its final newline
+// has an end index of 2, so nothing is written before the text.
+func appendTextRequest(text string, endIndex int64) *docs.Request {
+	if endIndex > 2 {
+		text = "\n" + text
+	}
+	return &docs.Request{
+		InsertText: &docs.InsertTextRequest{
+			Text:                 text,
+			EndOfSegmentLocation: &docs.EndOfSegmentLocation{},
+		},
+	}
+}
+
+func bodyEndIndex(doc *docs.Document) int64 {
+	if doc.Body == nil || len(doc.Body.Content) == 0 {
+		return 0
+	}
+	return doc.Body.Content[len(doc.Body.Content)-1].EndIndex
+}
+
+// writeStructuralText writes the text of document elements to b. Table cells
+// are separated by tabs, so each table row reads as one line.
+func writeStructuralText(b *strings.Builder, content []*docs.StructuralElement) {
+	for _, el := range content {
+		switch {
+		case el.Paragraph != nil:
+			for _, pe := range el.Paragraph.Elements {
+				if pe.TextRun != nil {
+					b.WriteString(pe.TextRun.Content)
+				}
+			}
+		case el.Table != nil:
+			for _, row := range el.Table.TableRows {
+				for i, cell := range row.TableCells {
+					if i > 0 {
+						b.WriteString("\t")
+					}
+					var cellText strings.Builder
+					writeStructuralText(&cellText, cell.Content)
+					b.WriteString(strings.TrimRight(cellText.String(), "\n"))
+				}
+				b.WriteString("\n")
+			}
+		case el.TableOfContents != nil:
+			writeStructuralText(b, el.TableOfContents.Content)
+		}
+	}
+}
+
+func (s *docsServer) CreateDocument(ctx context.Context, req *pb.CreateDocumentRequest) (*pb.CreateDocumentResponse, error) {
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A document title is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, docs.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Docs client: %v", err)
+	}
+
+	doc, err := srv.Documents.Create(&docs.Document{Title: req.Title}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to create document")
+	}
+
+	if req.InitialText != "" {
+		_, err := srv.Documents.BatchUpdate(doc.DocumentId, &docs.BatchUpdateDocumentRequest{
+			Requests: []*docs.Request{appendTextRequest(req.InitialText, bodyEndIndex(doc))},
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Document %s was created but its text could not be written", doc.DocumentId)
+		}
+	}
+
+	return &pb.CreateDocumentResponse{
+		Common:     &pb.CommonResponse{Status: "OK", Message: "Document created successfully."},
+		DocumentId: doc.DocumentId,
+		Title:      doc.Title,
+		Url:        documentURL(doc.DocumentId),
+	}, nil
+}
+
+func (s *docsServer) AppendText(ctx context.Context, req *pb.AppendTextRequest) (*pb.AppendTextResponse, error) {
+	if req.DocumentId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A document ID is required.")
+	}
+	if req.Text == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "No text to append.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, docs.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Docs client: %v", err)
+	}
+
+	doc, err := srv.Documents.Get(req.DocumentId).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get document")
+	}
+
+	_, err = srv.Documents.BatchUpdate(req.DocumentId, &docs.BatchUpdateDocumentRequest{
+		Requests: []*docs.Request{appendTextRequest(req.Text, bodyEndIndex(doc))},
+		// Fail instead of writing into the wrong place if the document changed since it was read.
+		WriteControl: &docs.WriteControl{RequiredRevisionId: doc.RevisionId},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to append text")
+	}
+
+	return &pb.AppendTextResponse{
+		Common:     &pb.CommonResponse{Status: "OK", Message: "Text appended successfully."},
+		DocumentId: req.DocumentId,
+		Url:        documentURL(req.DocumentId),
+	}, nil
+}
+
+func (s *docsServer) GetDocumentText(ctx context.Context, req *pb.GetDocumentTextRequest) (*pb.GetDocumentTextResponse, error) {
+	if req.DocumentId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A document ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, docs.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Docs client: %v", err)
+	}
+
+	doc, err := srv.Documents.Get(req.DocumentId).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get document")
+	}
+
+	var b strings.Builder
+	if doc.Body != nil {
+		writeStructuralText(&b, doc.Body.Content)
+	}
+
+	return &pb.GetDocumentTextResponse{
+		Common:     &pb.CommonResponse{Status: "OK", Message: "Document read successfully."},
+		DocumentId: doc.DocumentId,
+		Title:      doc.Title,
+		Text:       b.String(),
+	}, nil
+}
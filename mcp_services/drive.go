@@ -0,0 +1,369 @@
+// mcp_services/drive.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Default and maximum number of files returned by SearchFiles
+	defaultDriveResults = 10
+	maxDriveResults     = 100
+	// Largest file DownloadFile returns, leaving room under gRPC's default
+	// 4 MB message limit
+	maxDriveDownloadBytes = 3 << 20
+	// File fields returned by every Drive RPC
+	driveFileFields = "id,name,mimeType,size,modifiedTime,webViewLink,owners(emailAddress),parents,shared,description"
+
+	defaultDriveExportFormat = "pdf"
+	defaultDriveShareRole    = "reader"
+)
+
+// Google Workspace MIME types, which have no binary content and must be exported
+const (
+	googleAppsMimePrefix  = "application/vnd.google-apps."
+	googleDocMimeType     = "application/vnd.google-apps.document"
+	googleSheetMimeType   = "application/vnd.google-apps.spreadsheet"
+	googleSlidesMimeType  = "application/vnd.google-apps.presentation"
+	googleDrawingMimeType = "application/vnd.google-apps.drawing"
+)
+
+// driveExportFormats maps each Google Workspace type to the export formats
+// accepted by DownloadFile and their MIME types.
+var driveExportFormats = map[string]map[string]string{
+	googleDocMimeType: {
+		"pdf":  "application/pdf",
+		"text": "text/plain",
+		"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	},
+	googleSheetMimeType: {
+		"pdf":  "application/pdf",
+		"csv":  "text/csv", // Only the first sheet
+		"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	},
+	googleSlidesMimeType: {
+		"pdf":  "application/pdf",
+		"text": "text/plain",
+		"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	},
+	googleDrawingMimeType: {
+		"pdf": "application/pdf",
+		"png": "image/png",
+	},
+}
+
+// Roles accepted by ShareFile
+var driveShareRoles = map[string]bool{
+	"reader":    true,
+	"commenter": true,
+	"writer":    true,
+}
+
+// driveQueryEscaper escapes a value for a single-quoted Drive query string.
+var driveQueryEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// ====================================================================
+// Drive Service Implementation
+// ====================================================================
+type driveServer struct {
+	pb.UnimplementedDriveServiceServer
+}
+
+func driveFileToProto(f *drive.File) *pb.DriveFile {
+	var owners []string
+	for _, o := range f.Owners {
+		owners = append(owners, o.EmailAddress)
+	}
+	return &pb.DriveFile{
+		Id:           f.Id,
+		Name:         f.Name,
+		MimeType:     f.MimeType,
+		Size:         f.Size,
+		ModifiedTime: f.ModifiedTime,
+		WebViewLink:  f.WebViewLink,
+		Owners:       owners,
+		Parents:      f.Parents,
+		Shared:       f.Shared,
+		Description:  f.Description,
+	}
+}
+
+// buildDriveQuery combines a raw Drive query with the friendly name filter.
+func buildDriveQuery(query, nameContains string, includeTrashed bool) string {
+	var clauses []string
+	if q := strings.TrimSpace(query); q != "" {
+		clauses = append(clauses, "("+q+")")
+	}
+	if name := strings.TrimSpace(nameContains); name != "" {
+		clauses = append(clauses, fmt.Sprintf("name contains '%s'", driveQueryEscaper.Replace(name)))
+	}
+	if !includeTrashed {
+		clauses = append(clauses, "trashed = false")
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// readDriveContent reads a download body, failing if it exceeds maxDriveDownloadBytes.
+func readDriveContent(body io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(body, maxDriveDownloadBytes+1))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to read file content: %v", err)
+	}
+	if len(content) > maxDriveDownloadBytes {
+		return nil, status.Errorf(codes.FailedPrecondition, "The file is larger than %d MB; open it from its web link instead.", maxDriveDownloadBytes>>20)
+	}
+	return content, nil
+}
+
+func (s *driveServer) SearchFiles(ctx context.Context, req *pb.SearchFilesRequest) (*pb.SearchFilesResponse, error) {
+	limit := int64(req.MaxResults)
+	if limit <= 0 {
+		limit = defaultDriveResults
+	}
+	if limit > maxDriveResults {
+		limit = maxDriveResults
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+	}
+
+	resp, err := srv.Files.List().
+		Q(buildDriveQuery(req.Query, req.NameContains, req.IncludeTrashed)).
+		OrderBy("modifiedTime desc").
+		PageSize(limit).
+		Fields(googleapi.Field("files(" + driveFileFields + ")")).
+		Context(ctx).
+		Do()
+	if err != nil {
+		// An invalid query is reported by the API as a 400.
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusBadRequest {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid Drive query: %v", err)
+		}
+		return nil, googleAPIError(err, "Unable to search files")
+	}
+
+	var pbFiles []*pb.DriveFile
+	for _, f := range resp.Files {
+		pbFiles = append(pbFiles, driveFileToProto(f))
+	}
+
+	return &pb.SearchFilesResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Files searched successfully."},
+		Files:  pbFiles,
+	}, nil
+}
+
+func (s *driveServer) GetFileMetadata(ctx context.Context, req *pb.GetFileMetadataRequest) (*pb.GetFileMetadataResponse, error) {
+	if req.FileId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A file ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+	}
+
+	f, err := srv.Files.Get(req.FileId).Fields(driveFileFields).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get file")
+	}
+
+	return &pb.GetFileMetadataResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "File retrieved successfully."},
+		File:   driveFileToProto(f),
+	}, nil
+}
+
+func (s *driveServer) DownloadFile(ctx context.Context, req *pb.DownloadFileRequest) (*pb.DownloadFileResponse, error) {
+	if req.FileId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A file ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+	}
+
+	f, err := srv.Files.Get(req.FileId).Fields(driveFileFields).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get file")
+	}
+
+	mimeType := f.MimeType
+	var content []byte
+	if formats, ok := driveExportFormats[f.MimeType]; ok {
+		format := strings.ToLower(req.ExportFormat)
+		if format == "" {
+			format = defaultDriveExportFormat
+		}
+		exportMimeType, ok := formats[format]
+		if !ok {
+			var supported []string
+			for name := range formats {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			return nil, status.Errorf(codes.InvalidArgument, "Cannot export %s as %q; supported formats: %s.", f.Name, format, strings.Join(supported, ", "))
+		}
+
+		resp, err := srv.Files.Export(f.Id, exportMimeType).Context(ctx).Download()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to export file")
+		}
+		defer resp.Body.Close()
+		if content, err = readDriveContent(resp.Body); err != nil {
+			return nil, err
+		}
+		mimeType = exportMimeType
+	} else {
+		if strings.HasPrefix(f.MimeType, googleAppsMimePrefix) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Files of type %s cannot be downloaded.", f.MimeType)
+		}
+		if f.Size > maxDriveDownloadBytes {
+			return nil, status.Errorf(codes.FailedPrecondition, "The file is larger than %d MB; open it from its web link instead.", maxDriveDownloadBytes>>20)
+		}
+
+		resp, err := srv.Files.Get(f.Id).Context(ctx).Download()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to download file")
+		}
+		defer resp.Body.Close()
+		if content, err = readDriveContent(resp.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.DownloadFileResponse{
+		Common:   &pb.CommonResponse{Status: "OK", Message: "File downloaded successfully."},
+		File:     driveFileToProto(f),
+		Content:  content,
+		MimeType: mimeType,
+	}, nil
+}
+
+func (s *driveServer) UploadFile(ctx context.Context, req *pb.UploadFileRequest) (*pb.UploadFileResponse, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A file name is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+	}
+
+	file := &drive.File{
+		Name:        req.Name,
+		MimeType:    req.MimeType,
+		Description: req.Description,
+	}
+	if req.ParentFolderId != "" {
+		file.Parents = []string{req.ParentFolderId}
+	}
+
+	// For a Google Workspace type Drive converts the upload, so the content
+	// type is left to be detected from the content itself.
+	var media []googleapi.MediaOption
+	if req.MimeType != "" && !strings.HasPrefix(req.MimeType, googleAppsMimePrefix) {
+		media = append(media, googleapi.ContentType(req.MimeType))
+	}
+	created, err := srv.Files.Create(file).
+		Media(bytes.NewReader(req.Content), media...).
+		Fields(driveFileFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to upload file")
+	}
+
+	return &pb.UploadFileResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "File uploaded successfully."},
+		File:   driveFileToProto(created),
+	}, nil
+}
+
+func (s *driveServer) ShareFile(ctx context.Context, req *pb.ShareFileRequest) (*pb.ShareFileResponse, error) {
+	if req.FileId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A file ID is required.")
+	}
+	if !strings.Contains(req.Email, "@") {
+		return nil, status.Errorf(codes.InvalidArgument, "A valid email address is required to share a file.")
+	}
+	role := req.Role
+	if role == "" {
+		role = defaultDriveShareRole
+	}
+	if !driveShareRoles[role] {
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported role %q, expected reader, commenter or writer.", role)
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+	}
+
+	call := srv.Permissions.Create(req.FileId, &drive.Permission{
+		Type:         "user",
+		Role:         role,
+		EmailAddress: req.Email,
+	}).SendNotificationEmail(req.SendNotification)
+	if req.SendNotification && req.Message != "" {
+		call = call.EmailMessage(req.Message)
+	}
+	permission, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to share file")
+	}
+
+	f, err := srv.Files.Get(req.FileId).Fields(driveFileFields).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get file")
+	}
+
+	return &pb.ShareFileResponse{
+		Common:       &pb.CommonResponse{Status: "OK", Message: fmt.Sprintf("File shared with %s as %s.", req.Email, role)},
+		PermissionId: permission.Id,
+		File:         driveFileToProto(f),
+	}, nil
+}
@@ -0,0 +1,112 @@
+// mcp_services/gmail_batch.go
+package main
+
+import (
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Maximum number of message IDs accepted by a single batchModify call
+	batchModifyChunkSize = 1000
+	// Page size used when resolving the messages matching a query
+	listMessagesPageSize = 500
+	// System label that moves messages to the trash when added
+	trashLabelID = "TRASH"
+)
+
+// listMessageIDs returns the IDs of every message matching query, following
+// all result pages.
+func listMessageIDs(ctx context.Context, srv *gmail.Service, query string) ([]string, error) {
+	var ids []string
+	err := srv.Users.Messages.List("me").Q(query).MaxResults(listMessagesPageSize).Pages(ctx, func(page *gmail.ListMessagesResponse) error {
+		for _, msg := range page.Messages {
+			ids = append(ids, msg.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *gmailServer) BatchModifyByQuery(ctx context.Context, req *pb.BatchModifyByQueryRequest) (*pb.BatchModifyByQueryResponse, error) {
+	// An empty query matches the whole mailbox, which is never what the user means.
+	if req.Query == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A search query is required.")
+	}
+	if !req.DryRun && len(req.AddLabels) == 0 && len(req.RemoveLabels) == 0 && !req.MarkAsRead && !req.Trash {
+		return nil, status.Errorf(codes.InvalidArgument, "At least one action is required unless dry_run is set.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	ids, err := listMessageIDs(ctx, srv, req.Query)
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list messages")
+	}
+
+	if req.DryRun {
+		return &pb.BatchModifyByQueryResponse{
+			Common:       &pb.CommonResponse{Status: "OK", Message: "Dry run completed, no messages were modified."},
+			MatchedCount: int32(len(ids)),
+			DryRun:       true,
+		}, nil
+	}
+
+	labels, err := newLabelResolver(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	addIDs, err := labels.IDs(ctx, req.AddLabels, true)
+	if err != nil {
+		return nil, err
+	}
+	removeIDs, err := labels.IDs(ctx, req.RemoveLabels, false)
+	if err != nil {
+		return nil, err
+	}
+	if req.MarkAsRead {
+		removeIDs = append(removeIDs, unreadLabelID)
+	}
+	if req.Trash {
+		addIDs = append(addIDs, trashLabelID)
+	}
+
+	modified := 0
+	for start := 0; start < len(ids); start += batchModifyChunkSize {
+		end := start + batchModifyChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		err := srv.Users.Messages.BatchModify("me", &gmail.BatchModifyMessagesRequest{
+			Ids:            ids[start:end],
+			AddLabelIds:    addIDs,
+			RemoveLabelIds: removeIDs,
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to modify messages (%d of %d modified)", modified, len(ids))
+		}
+		modified = end
+	}
+
+	return &pb.BatchModifyByQueryResponse{
+		Common:        &pb.CommonResponse{Status: "OK", Message: "Messages modified successfully."},
+		MatchedCount:  int32(len(ids)),
+		ModifiedCount: int32(modified),
+	}, nil
+}
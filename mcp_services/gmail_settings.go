@@ -0,0 +1,341 @@
+// mcp_services/gmail_settings.go
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// System labels that back the archive and mark-as-read filter actions
+const (
+	inboxLabelID  = "INBOX"
+	unreadLabelID = "UNREAD"
+)
+
+// vacationToProto converts Gmail vacation settings, which use epoch
+// milliseconds, to the RFC3339 based pb.VacationSettings.
+func vacationToProto(v *gmail.VacationSettings) *pb.VacationSettings {
+	settings := &pb.VacationSettings{
+		EnableAutoReply:    v.EnableAutoReply,
+		ResponseSubject:    v.ResponseSubject,
+		ResponseBody:       v.ResponseBodyPlainText,
+		RestrictToContacts: v.RestrictToContacts,
+		RestrictToDomain:   v.RestrictToDomain,
+	}
+	if v.StartTime != 0 {
+		settings.StartTime = time.UnixMilli(v.StartTime).Format(time.RFC3339)
+	}
+	if v.EndTime != 0 {
+		settings.EndTime = time.UnixMilli(v.EndTime).Format(time.RFC3339)
+	}
+	return settings
+}
+
+func vacationFromProto(settings *pb.VacationSettings) (*gmail.VacationSettings, error) {
+	v := &gmail.VacationSettings{
+		EnableAutoReply:       settings.EnableAutoReply,
+		ResponseSubject:       settings.ResponseSubject,
+		ResponseBodyPlainText: settings.ResponseBody,
+		RestrictToContacts:    settings.RestrictToContacts,
+		RestrictToDomain:      settings.RestrictToDomain,
+		// Send the booleans even when false, otherwise turning the responder off is a no-op.
+		ForceSendFields: []string{"EnableAutoReply", "RestrictToContacts", "RestrictToDomain"},
+	}
+	if settings.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, settings.StartTime)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "start_time must be in RFC3339 format: %v", err)
+		}
+		v.StartTime = t.UnixMilli()
+	}
+	if settings.EndTime != "" {
+		t, err := time.Parse(time.RFC3339, settings.EndTime)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "end_time must be in RFC3339 format: %v", err)
+		}
+		v.EndTime = t.UnixMilli()
+	}
+	if v.StartTime != 0 && v.EndTime != 0 && v.EndTime <= v.StartTime {
+		return nil, status.Errorf(codes.InvalidArgument, "end_time must be after start_time.")
+	}
+	return v, nil
+}
+
+func (s *gmailServer) GetVacationSettings(ctx context.Context, req *pb.GetVacationSettingsRequest) (*pb.GetVacationSettingsResponse, error) {
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	vacation, err := srv.Users.Settings.GetVacation("me").Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get vacation settings")
+	}
+
+	return &pb.GetVacationSettingsResponse{
+		Common:   &pb.CommonResponse{Status: "OK", Message: "Vacation settings retrieved successfully."},
+		Settings: vacationToProto(vacation),
+	}, nil
+}
+
+func (s *gmailServer) UpdateVacationSettings(ctx context.Context, req *pb.UpdateVacationSettingsRequest) (*pb.UpdateVacationSettingsResponse, error) {
+	if req.Settings == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Vacation settings are required.")
+	}
+	vacation, err := vacationFromProto(req.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	updated, err := srv.Users.Settings.UpdateVacation("me", vacation).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to update vacation settings")
+	}
+
+	return &pb.UpdateVacationSettingsResponse{
+		Common:   &pb.CommonResponse{Status: "OK", Message: "Vacation settings updated successfully."},
+		Settings: vacationToProto(updated),
+	}, nil
+}
+
+// labelResolver maps label names to IDs and back for a Gmail account.
+// Lookups by name are case-insensitive, as in the Gmail UI.
+type labelResolver struct {
+	srv    *gmail.Service
+	byID   map[string]*gmail.Label
+	byName map[string]*gmail.Label
+}
+
+func newLabelResolver(ctx context.Context, srv *gmail.Service) (*labelResolver, error) {
+	labels, err := srv.Users.Labels.List("me").Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list labels")
+	}
+	r := &labelResolver{
+		srv:    srv,
+		byID:   make(map[string]*gmail.Label),
+		byName: make(map[string]*gmail.Label),
+	}
+	for _, l := range labels.Labels {
+		r.byID[l.Id] = l
+		r.byName[strings.ToLower(l.Name)] = l
+	}
+	return r, nil
+}
+
+// IDs resolves label IDs or names to IDs. Unknown names become new user
+// labels if create is set, and are an InvalidArgument error otherwise.
+func (r *labelResolver) IDs(ctx context.Context, labels []string, create bool) ([]string, error) {
+	var ids []string
+	for _, label := range labels {
+		if l, ok := r.byID[label]; ok {
+			ids = append(ids, l.Id)
+			continue
+		}
+		if l, ok := r.byName[strings.ToLower(label)]; ok {
+			ids = append(ids, l.Id)
+			continue
+		}
+		if !create {
+			return nil, status.Errorf(codes.InvalidArgument, "Label %q does not exist.", label)
+		}
+		l, err := r.srv.Users.Labels.Create("me", &gmail.Label{
+			Name:                  label,
+			LabelListVisibility:   "labelShow",
+			MessageListVisibility: "show",
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to create label %q", label)
+		}
+		r.byID[l.Id] = l
+		r.byName[strings.ToLower(l.Name)] = l
+		ids = append(ids, l.Id)
+	}
+	return ids, nil
+}
+
+// Name returns the display name of a label ID, or the ID itself if unknown.
+func (r *labelResolver) Name(id string) string {
+	if l, ok := r.byID[id]; ok {
+		return l.Name
+	}
+	return id
+}
+
+// filterToProto converts a Gmail filter, folding the INBOX and UNREAD label
+// removals back into the archive and mark_as_read actions.
+func filterToProto(f *gmail.Filter, labels *labelResolver) *pb.Filter {
+	filter := &pb.Filter{
+		Id:       f.Id,
+		Criteria: &pb.FilterCriteria{},
+		Action:   &pb.FilterAction{},
+	}
+	if c := f.Criteria; c != nil {
+		filter.Criteria = &pb.FilterCriteria{
+			From:          c.From,
+			To:            c.To,
+			Subject:       c.Subject,
+			Query:         c.Query,
+			NegatedQuery:  c.NegatedQuery,
+			HasAttachment: c.HasAttachment,
+		}
+	}
+	if a := f.Action; a != nil {
+		for _, id := range a.AddLabelIds {
+			filter.Action.AddLabels = append(filter.Action.AddLabels, labels.Name(id))
+		}
+		for _, id := range a.RemoveLabelIds {
+			switch id {
+			case inboxLabelID:
+				filter.Action.Archive = true
+			case unreadLabelID:
+				filter.Action.MarkAsRead = true
+			default:
+				filter.Action.RemoveLabels = append(filter.Action.RemoveLabels, labels.Name(id))
+			}
+		}
+	}
+	return filter
+}
+
+func (s *gmailServer) ListFilters(ctx context.Context, req *pb.ListFiltersRequest) (*pb.ListFiltersResponse, error) {
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	filters, err := srv.Users.Settings.Filters.List("me").Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list filters")
+	}
+	labels, err := newLabelResolver(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	var pbFilters []*pb.Filter
+	for _, f := range filters.Filter {
+		pbFilters = append(pbFilters, filterToProto(f, labels))
+	}
+
+	return &pb.ListFiltersResponse{
+		Common:  &pb.CommonResponse{Status: "OK", Message: "Filters listed successfully."},
+		Filters: pbFilters,
+	}, nil
+}
+
+func (s *gmailServer) CreateFilter(ctx context.Context, req *pb.CreateFilterRequest) (*pb.CreateFilterResponse, error) {
+	c := req.Criteria
+	if c == nil || (c.From == "" && c.To == "" && c.Subject == "" && c.Query == "" && c.NegatedQuery == "" && !c.HasAttachment) {
+		return nil, status.Errorf(codes.InvalidArgument, "At least one filter criterion is required.")
+	}
+	a := req.Action
+	if a == nil || (len(a.AddLabels) == 0 && len(a.RemoveLabels) == 0 && !a.Archive && !a.MarkAsRead) {
+		return nil, status.Errorf(codes.InvalidArgument, "At least one filter action is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	labels, err := newLabelResolver(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	addIDs, err := labels.IDs(ctx, a.AddLabels, true)
+	if err != nil {
+		return nil, err
+	}
+	removeIDs, err := labels.IDs(ctx, a.RemoveLabels, false)
+	if err != nil {
+		return nil, err
+	}
+	if a.Archive {
+		removeIDs = append(removeIDs, inboxLabelID)
+	}
+	if a.MarkAsRead {
+		removeIDs = append(removeIDs, unreadLabelID)
+	}
+
+	filter := &gmail.Filter{
+		Criteria: &gmail.FilterCriteria{
+			From:          c.From,
+			To:            c.To,
+			Subject:       c.Subject,
+			Query:         c.Query,
+			NegatedQuery:  c.NegatedQuery,
+			HasAttachment: c.HasAttachment,
+		},
+		Action: &gmail.FilterAction{
+			AddLabelIds:    addIDs,
+			RemoveLabelIds: removeIDs,
+		},
+	}
+
+	created, err := srv.Users.Settings.Filters.Create("me", filter).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to create filter")
+	}
+
+	return &pb.CreateFilterResponse{
+		Common:        &pb.CommonResponse{Status: "OK", Message: "Filter created successfully."},
+		CreatedFilter: filterToProto(created, labels),
+	}, nil
+}
+
+func (s *gmailServer) DeleteFilter(ctx context.Context, req *pb.DeleteFilterRequest) (*pb.DeleteFilterResponse, error) {
+	if req.FilterId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A filter ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	if err := srv.Users.Settings.Filters.Delete("me", req.FilterId).Context(ctx).Do(); err != nil {
+		return nil, googleAPIError(err, "Unable to delete filter")
+	}
+
+	return &pb.DeleteFilterResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Filter deleted successfully."},
+	}, nil
+}
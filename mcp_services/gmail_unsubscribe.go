@@ -0,0 +1,269 @@
+// mcp_services/gmail_unsubscribe.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Unsubscribe methods, as exposed in pb.UnsubscribeFromSenderResponse.Method
+const (
+	unsubscribeOneClick = "ONE_CLICK"
+	unsubscribeMailto   = "MAILTO"
+	unsubscribeManual   = "MANUAL"
+)
+
+const (
+	// Body of an RFC 8058 one-click unsubscribe request, also the expected
+	// value of the List-Unsubscribe-Post header
+	oneClickUnsubscribeBody = "List-Unsubscribe=One-Click"
+	// Timeout for one-click unsubscribe requests when no client is injected
+	unsubscribeTimeout = 15 * time.Second
+)
+
+var (
+	errUnsubscribeRedirect = errors.New("unsubscribe endpoint redirected, which one-click unsubscribes do not allow")
+	// Default client for one-click unsubscribes, see newUnsubscribeHTTPClient
+	defaultUnsubscribeClient = newUnsubscribeHTTPClient()
+)
+
+// newUnsubscribeHTTPClient returns a client for one-click unsubscribe
+// requests. Their URL is chosen by the sender of an email, so the client only
+// connects to public addresses, without proxies, and does not follow
+// redirects.
+func newUnsubscribeHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: unsubscribeTimeout, Control: refuseNonPublicAddress}
+	return &http.Client{
+		Timeout: unsubscribeTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: unsubscribeTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errUnsubscribeRedirect
+		},
+	}
+}
+
+// refuseNonPublicAddress is a net.Dialer Control hook refusing connections
+// to loopback, private, link-local and other non-public addresses. It runs
+// after name resolution, so it also covers names resolving to them.
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("unexpected address %q", address)
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || carrierGradeNAT.Contains(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+// Shared address space of RFC 6598, not covered by netip.Addr.IsPrivate
+var carrierGradeNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// unsubscribeHTTPClient returns the client used for one-click unsubscribe requests.
+func (s *gmailServer) unsubscribeHTTPClient() *http.Client {
+	if s.httpClient != nil {
+		return s.httpClient
+	}
+	return defaultUnsubscribeClient
+}
+
+// parseListUnsubscribe returns the URIs of a List-Unsubscribe header
+// (RFC 2369), e.g. "<mailto:leave@example.com>, <https://example.com/u/1>".
+// Only the bracketed entries are URIs; they may themselves contain commas,
+// and whitespace inside the brackets (e.g. from header folding) is ignored.
+func parseListUnsubscribe(header string) []string {
+	var uris []string
+	for {
+		start := strings.IndexByte(header, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(header[start:], '>')
+		if end < 0 {
+			break
+		}
+		if uri := strings.Join(strings.Fields(header[start+1:start+end]), ""); uri != "" {
+			uris = append(uris, uri)
+		}
+		header = header[start+end+1:]
+	}
+	return uris
+}
+
+// oneClickUnsubscribe performs an RFC 8058 one-click unsubscribe request.
+func oneClickUnsubscribe(ctx context.Context, client *http.Client, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(oneClickUnsubscribeBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unsubscribe endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// mailtoUnsubscribeMessage builds the email requested by a mailto: unsubscribe URI.
+func mailtoUnsubscribeMessage(uri string) (*gmail.Message, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	to := u.Opaque
+	if to == "" {
+		to = u.Path
+	}
+	if to == "" {
+		return nil, fmt.Errorf("mailto URI %q has no address", uri)
+	}
+	if to, err = url.PathUnescape(to); err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	subject := query.Get("subject")
+	if subject == "" {
+		subject = "unsubscribe"
+	}
+	// The URI comes from the sender; line breaks would let it add headers
+	// such as Bcc to an email sent from the user's account.
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return nil, fmt.Errorf("mailto URI %q contains line breaks", uri)
+	}
+	addr, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", to, err)
+	}
+	to = addr.Address
+	body := query.Get("body")
+	if body == "" {
+		body = "unsubscribe"
+	}
+	return newRawMessage(to, subject, body), nil
+}
+
+func (s *gmailServer) UnsubscribeFromSender(ctx context.Context, req *pb.UnsubscribeFromSenderRequest) (*pb.UnsubscribeFromSenderResponse, error) {
+	if req.MessageId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A message ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	msg, err := srv.Users.Messages.Get("me", req.MessageId).Format("metadata").
+		MetadataHeaders("From", "List-Unsubscribe", "List-Unsubscribe-Post").Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to get message")
+	}
+
+	var from, listUnsubscribe, listUnsubscribePost string
+	for _, h := range msg.Payload.Headers {
+		switch strings.ToLower(h.Name) {
+		case "from":
+			from = h.Value
+		case "list-unsubscribe":
+			listUnsubscribe = h.Value
+		case "list-unsubscribe-post":
+			listUnsubscribePost = h.Value
+		}
+	}
+
+	sender := from
+	if addr, err := mail.ParseAddress(from); err == nil {
+		sender = addr.Address
+	}
+
+	uris := parseListUnsubscribe(listUnsubscribe)
+	if len(uris) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Message %s has no List-Unsubscribe header.", req.MessageId)
+	}
+	var httpsURI, mailtoURI string
+	for _, uri := range uris {
+		lower := strings.ToLower(uri)
+		switch {
+		case strings.HasPrefix(lower, "https://") && httpsURI == "":
+			httpsURI = uri
+		case strings.HasPrefix(lower, "mailto:") && mailtoURI == "":
+			mailtoURI = uri
+		}
+	}
+
+	resp := &pb.UnsubscribeFromSenderResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Unsubscribed successfully."},
+		Sender: sender,
+	}
+	switch {
+	// RFC 8058 only allows one-click requests to HTTPS URIs that opted in with List-Unsubscribe-Post.
+	case httpsURI != "" && strings.EqualFold(strings.TrimSpace(listUnsubscribePost), oneClickUnsubscribeBody):
+		if err := oneClickUnsubscribe(ctx, s.unsubscribeHTTPClient(), httpsURI); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "One-click unsubscribe failed: %v", err)
+		}
+		resp.Method = unsubscribeOneClick
+	case mailtoURI != "":
+		message, err := mailtoUnsubscribeMessage(mailtoURI)
+		if err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "Invalid mailto unsubscribe URI: %v", err)
+		}
+		if _, err := srv.Users.Messages.Send("me", message).Context(ctx).Do(); err != nil {
+			return nil, googleAPIError(err, "Unable to send unsubscribe email")
+		}
+		resp.Method = unsubscribeMailto
+	default:
+		// Plain web links may need the user to confirm on a page, so we don't open them ourselves.
+		resp.Method = unsubscribeManual
+		resp.UnsubscribeUrl = uris[0]
+		resp.Common.Message = "The sender requires unsubscribing through its website."
+	}
+
+	if req.CreateArchiveFilter && sender != "" {
+		filter, err := srv.Users.Settings.Filters.Create("me", &gmail.Filter{
+			Criteria: &gmail.FilterCriteria{From: sender},
+			Action:   &gmail.FilterAction{RemoveLabelIds: []string{inboxLabelID}},
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unsubscribed, but unable to create archive filter")
+		}
+		resp.FilterId = filter.Id
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,179 @@
+// mcp_services/gmail_unsubscribe_test.go
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"<mailto:leave@example.com>, <https://example.com/u/1>", []string{"mailto:leave@example.com", "https://example.com/u/1"}},
+		{" < https://example.com/u/2 > ", []string{"https://example.com/u/2"}},
+		{"<https://example.com/u?list=a,b&id=3>, <mailto:leave@example.com?subject=a,b>",
+			[]string{"https://example.com/u?list=a,b&id=3", "mailto:leave@example.com?subject=a,b"}},
+		{"<https://example.com/u/\r\n 4>", []string{"https://example.com/u/4"}},
+		{"<mailto:leave@example.com> (List), <https://example.com/u/5", []string{"mailto:leave@example.com"}},
+		{"https://example.com/no-brackets", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseListUnsubscribe(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseListUnsubscribe(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// rawHeaders returns the header section of a message built by newRawMessage.
+func rawHeaders(t *testing.T, raw string) string {
+	t.Helper()
+	decoded, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil {
+		t.Fatalf("decoding raw message: %v", err)
+	}
+	headers, _, _ := strings.Cut(string(decoded), "\r\n\r\n")
+	return headers
+}
+
+func TestMailtoUnsubscribeMessage(t *testing.T) {
+	msg, err := mailtoUnsubscribeMessage("mailto:leave@example.com?subject=Remove%20me")
+	if err != nil {
+		t.Fatalf("mailtoUnsubscribeMessage: %v", err)
+	}
+	if got, want := rawHeaders(t, msg.Raw), "To: leave@example.com\r\nSubject: Remove me"; got != want {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+
+	msg, err = mailtoUnsubscribeMessage("mailto:leave@example.com")
+	if err != nil {
+		t.Fatalf("mailtoUnsubscribeMessage: %v", err)
+	}
+	if got, want := rawHeaders(t, msg.Raw), "To: leave@example.com\r\nSubject: unsubscribe"; got != want {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+}
+
+func TestMailtoUnsubscribeMessageRejectsInjection(t *testing.T) {
+	for _, uri := range []string{
+		"mailto:leave@example.com%0D%0ABcc:victim@example.com",
+		"mailto:leave@example.com%0ABcc:victim@example.com",
+		"mailto:leave@example.com?subject=x%0D%0ABcc:%20victim@example.com",
+		"mailto:not-an-address",
+		"mailto:a@example.com,b@example.com",
+		"mailto:",
+	} {
+		if msg, err := mailtoUnsubscribeMessage(uri); err == nil {
+			t.Errorf("mailtoUnsubscribeMessage(%q) = %q, want an error", uri, rawHeaders(t, msg.Raw))
+		}
+	}
+}
+
+func TestNewRawMessageEncodesSubject(t *testing.T) {
+	headers := rawHeaders(t, newRawMessage("a@example.com", "Hola\r\nBcc: victim@example.com", "body").Raw)
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("subject injected a header: %q", headers)
+	}
+	if strings.Count(headers, "\r\n") != 1 {
+		t.Errorf("headers = %q, want exactly To and Subject", headers)
+	}
+}
+
+func TestOneClickUnsubscribe(t *testing.T) {
+	var method, contentType, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, contentType, body = r.Method, r.Header.Get("Content-Type"), string(b)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	if err := oneClickUnsubscribe(context.Background(), srv.Client(), srv.URL+"/u/1"); err != nil {
+		t.Fatalf("oneClickUnsubscribe: %v", err)
+	}
+	if method != http.MethodPost || contentType != "application/x-www-form-urlencoded" || body != oneClickUnsubscribeBody {
+		t.Errorf("request = %s %q %q, want POST of %q as a form", method, contentType, body, oneClickUnsubscribeBody)
+	}
+
+	if err := oneClickUnsubscribe(context.Background(), srv.Client(), srv.URL+"/fail"); err == nil {
+		t.Error("oneClickUnsubscribe succeeded on a 500 response")
+	}
+}
+
+func TestUnsubscribeClientRefusesRedirects(t *testing.T) {
+	var redirected atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected.Store(true)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	// Allow loopback, so only the redirect policy is under test.
+	client := newUnsubscribeHTTPClient()
+	client.Transport = srv.Client().Transport
+
+	err := oneClickUnsubscribe(context.Background(), client, srv.URL+"/u/1")
+	if !errors.Is(err, errUnsubscribeRedirect) {
+		t.Errorf("oneClickUnsubscribe error = %v, want %v", err, errUnsubscribeRedirect)
+	}
+	if redirected.Load() {
+		t.Error("the redirect was followed")
+	}
+}
+
+func TestUnsubscribeClientRefusesNonPublicAddresses(t *testing.T) {
+	var hit atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit.Store(true)
+	}))
+	defer srv.Close()
+
+	if err := oneClickUnsubscribe(context.Background(), newUnsubscribeHTTPClient(), srv.URL+"/u/1"); err == nil {
+		t.Error("oneClickUnsubscribe to a loopback address succeeded")
+	}
+	if hit.Load() {
+		t.Error("the loopback server was reached")
+	}
+}
+
+func TestRefuseNonPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"127.0.0.1:443", true},
+		{"[::1]:443", true},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:443", true},
+		{"192.168.1.1:443", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:443", true},
+		{"[fd00::1]:443", true},
+		{"100.64.0.1:443", true},
+		{"0.0.0.0:443", true},
+		{"[::ffff:127.0.0.1]:443", true},
+		{"8.8.8.8:443", false},
+		{"[2001:4860:4860::8888]:443", false},
+	}
+	for _, tt := range tests {
+		err := refuseNonPublicAddress("tcp", tt.address, nil)
+		if refused := err != nil; refused != tt.refused {
+			t.Errorf("refuseNonPublicAddress(%q) = %v, want refused %v", tt.address, err, tt.refused)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// mcp_services/google_clients.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+)
+
+// How long the Google API clients of a user are reused after being created
+const googleClientTTL = 30 * time.Minute
+
+// googleClients caches the Google API clients of each user.
+var googleClients = newGoogleClientCache(googleClientTTL)
+
+// googleClientEntry holds the HTTP client of a user and the API services
+// built on it, keyed by service type.
+type googleClientEntry struct {
+	accessToken string
+	expires     time.Time
+	client      *http.Client
+	services    map[reflect.Type]interface{}
+}
+
+// googleClientCache keeps the HTTP client and API services of each user, so
+// requests do not rebuild them. An entry is rebuilt once it is older than
+// the TTL or when the user's access token changed, and dropped when the user
+// revokes access.
+type googleClientCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*googleClientEntry // keyed by user ID
+	lastSweep time.Time
+}
+
+func newGoogleClientCache(ttl time.Duration) *googleClientCache {
+	return &googleClientCache{ttl: ttl, entries: make(map[string]*googleClientEntry), lastSweep: time.Now()}
+}
+
+// service returns the service of type t for userID, built with newService
+// the first time it is requested for tok.
+func (c *googleClientCache) service(userID string, tok *oauth2.Token, t reflect.Type, newService func(*http.Client) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		c.lastSweep = now
+		for id, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, id)
+			}
+		}
+	}
+
+	e, ok := c.entries[userID]
+	if !ok || e.accessToken != tok.AccessToken || now.After(e.expires) {
+		// The client outlives the request, so it must not refresh the
+		// token with the request's context.
+		e = &googleClientEntry{
+			accessToken: tok.AccessToken,
+			expires:     now.Add(c.ttl),
+			client:      googleClient(context.Background(), tok),
+			services:    make(map[reflect.Type]interface{}),
+		}
+		c.entries[userID] = e
+	}
+
+	if srv, ok := e.services[t]; ok {
+		return srv, nil
+	}
+	srv, err := newService(e.client)
+	if err != nil {
+		return nil, err
+	}
+	e.services[t] = srv
+	return srv, nil
+}
+
+// Invalidate drops the clients of userID.
+func (c *googleClientCache) Invalidate(userID string) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// googleService returns the API service newService builds for tok, such as
+// calendar.NewService. Services of a user are shared across requests;
+// requests without a user ID get a new one.
+func googleService[T any](ctx context.Context, userID string, tok *oauth2.Token, newService func(context.Context, ...option.ClientOption) (*T, error)) (*T, error) {
+	if userID == "" {
+		return newService(ctx, option.WithHTTPClient(googleClient(ctx, tok)))
+	}
+	srv, err := googleClients.service(userID, tok, reflect.TypeFor[T](), func(client *http.Client) (interface{}, error) {
+		return newService(context.Background(), option.WithHTTPClient(client))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return srv.(*T), nil
+}
@@ -0,0 +1,72 @@
+// mcp_services/google_clients_test.go
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+)
+
+// BenchmarkGoogleService compares getting a Gmail service from the client
+// cache with building a new one, as requests without a user ID do.
+func BenchmarkGoogleService(b *testing.B) {
+	if googleOAuthConfig == nil {
+		googleOAuthConfig = &oauth2.Config{}
+		b.Cleanup(func() { googleOAuthConfig = nil })
+	}
+	tok := &oauth2.Token{AccessToken: "ya29.bench", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}
+	ctx := context.Background()
+
+	b.Run("cached", func(b *testing.B) {
+		const userID = "bench-user"
+		defer googleClients.Invalidate(userID)
+		for i := 0; i < b.N; i++ {
+			if _, err := googleService(ctx, userID, tok, gmail.NewService); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("fresh", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := googleService(ctx, "", tok, gmail.NewService); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGoogleClientCacheReusesServices(t *testing.T) {
+	if googleOAuthConfig == nil {
+		googleOAuthConfig = &oauth2.Config{}
+		t.Cleanup(func() { googleOAuthConfig = nil })
+	}
+	const userID = "cache-user"
+	defer googleClients.Invalidate(userID)
+	ctx := context.Background()
+	tok := &oauth2.Token{AccessToken: "ya29.first", Expiry: time.Now().Add(time.Hour)}
+
+	first, err := googleService(ctx, userID, tok, gmail.NewService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := googleService(ctx, userID, tok, gmail.NewService); again != first {
+		t.Error("the same token got a new service")
+	}
+
+	refreshed := &oauth2.Token{AccessToken: "ya29.second", Expiry: time.Now().Add(time.Hour)}
+	second, err := googleService(ctx, userID, refreshed, gmail.NewService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Error("a refreshed token reused the service of the old one")
+	}
+
+	googleClients.Invalidate(userID)
+	if third, _ := googleService(ctx, userID, refreshed, gmail.NewService); third == second {
+		t.Error("an invalidated user reused their service")
+	}
+}
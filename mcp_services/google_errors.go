@@ -0,0 +1,223 @@
+// mcp_services/google_errors.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// Retry delay reported for quota errors that do not say when to retry
+	defaultQuotaRetryDelay = time.Minute
+	// ErrorInfo domain and reason of calls made without the scopes they need.
+	// Google uses the same ones in its own errors.
+	errorInfoDomain         = "googleapis.com"
+	scopeInsufficientReason = "ACCESS_TOKEN_SCOPE_INSUFFICIENT"
+	// ErrorInfo reason of calls whose stored refresh token Google rejected
+	authorizationRevokedReason = "AUTHORIZATION_REVOKED"
+)
+
+// Reasons of the v1 error format that Calendar and Gmail answer with HTTP 403
+// although they are quota errors.
+var quotaReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+	"dailyLimitExceeded":    true,
+	"quotaExceeded":         true,
+}
+
+// httpCodes maps HTTP status codes of Google API errors to gRPC codes, for
+// errors whose body does not carry a canonical status.
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusGone:                codes.NotFound,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	499:                            codes.Canceled,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusBadGateway:          codes.Unavailable,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// googleAPIError translates err, returned by a Google API call, into a gRPC
+// error with the matching code. Its message is the formatted description of
+// what failed followed by Google's message. Quota errors carry a RetryInfo
+// detail, invalid requests a BadRequest detail with the offending fields, and
+// calls lacking scopes an ErrorInfo detail so scopeInterceptor can attach a
+// link to grant them.
+func googleAPIError(err error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+
+	var retrieveErr *oauth2.RetrieveError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "%s: %v", msg, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Errorf(codes.DeadlineExceeded, "%s: %v", msg, err)
+	case errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant":
+		// The refresh token was revoked or expired; only a new authorization helps.
+		return withDetails(status.Newf(codes.Unauthenticated, "%s: Google rejected the stored authorization: %v", msg, retrieveErr),
+			&errdetails.ErrorInfo{Reason: authorizationRevokedReason, Domain: errorInfoDomain})
+	}
+
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+	detail := gerr.Message
+	if detail == "" {
+		detail = err.Error()
+	}
+	apiErr, _ := apierror.FromError(gerr)
+
+	code := googleErrorCode(gerr)
+	st := status.Newf(code, "%s: %s", msg, detail)
+	switch code {
+	case codes.ResourceExhausted:
+		return withDetails(st, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay(gerr, apiErr))})
+	case codes.InvalidArgument:
+		if br := badRequest(gerr, apiErr); br != nil {
+			return withDetails(st, br)
+		}
+	case codes.PermissionDenied:
+		if isScopeInsufficient(gerr, apiErr) {
+			return withDetails(st, &errdetails.ErrorInfo{Reason: scopeInsufficientReason, Domain: errorInfoDomain})
+		}
+	}
+	return st.Err()
+}
+
+// withDetails returns st with details attached, or st alone if they cannot be.
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// googleErrorCode returns the gRPC code of a Google API error: a quota error
+// is ResourceExhausted whatever its HTTP status, then the canonical status
+// in the body is used if there is one, and the HTTP status otherwise.
+func googleErrorCode(gerr *googleapi.Error) codes.Code {
+	for _, item := range gerr.Errors {
+		if quotaReasons[item.Reason] {
+			return codes.ResourceExhausted
+		}
+	}
+
+	var body struct {
+		Error struct {
+			Status string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(gerr.Body), &body) == nil && body.Error.Status != "" {
+		var c codes.Code
+		if c.UnmarshalJSON([]byte(strconv.Quote(body.Error.Status))) == nil {
+			return c
+		}
+	}
+
+	if c, ok := httpCodes[gerr.Code]; ok {
+		return c
+	}
+	switch {
+	case gerr.Code >= 500:
+		return codes.Unavailable
+	case gerr.Code >= 400:
+		return codes.FailedPrecondition
+	}
+	return codes.Unknown
+}
+
+// retryDelay returns how long to wait before retrying a quota error: the
+// RetryInfo Google sent, else its Retry-After header, else
+// defaultQuotaRetryDelay.
+func retryDelay(gerr *googleapi.Error, apiErr *apierror.APIError) time.Duration {
+	if apiErr != nil {
+		if ri := apiErr.Details().RetryInfo; ri != nil && ri.RetryDelay != nil {
+			return ri.RetryDelay.AsDuration()
+		}
+	}
+	if d, ok := parseRetryAfter(gerr.Header.Get("Retry-After"), time.Now()); ok {
+		return d
+	}
+	return defaultQuotaRetryDelay
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// badRequest returns the field violations of an invalid request: the
+// BadRequest detail Google sent, else one violation per error item of the
+// v1 error format, which does not name the field.
+func badRequest(gerr *googleapi.Error, apiErr *apierror.APIError) *errdetails.BadRequest {
+	if apiErr != nil {
+		if br := apiErr.Details().BadRequest; br != nil && len(br.FieldViolations) > 0 {
+			return br
+		}
+	}
+	var br errdetails.BadRequest
+	for _, item := range gerr.Errors {
+		if item.Message == "" {
+			continue
+		}
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Description: item.Message,
+			Reason:      item.Reason,
+		})
+	}
+	if len(br.FieldViolations) == 0 {
+		return nil
+	}
+	return &br
+}
+
+// isScopeInsufficient reports whether a 403 was caused by a token without
+// the scopes the call needs, rather than by the user lacking access to the
+// resource.
+func isScopeInsufficient(gerr *googleapi.Error, apiErr *apierror.APIError) bool {
+	if apiErr != nil && apiErr.Reason() == scopeInsufficientReason {
+		return true
+	}
+	for _, item := range gerr.Errors {
+		if item.Reason == "insufficientPermissions" {
+			return true
+		}
+	}
+	return false
+}
@@ -5,17 +5,19 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	// Google API clients
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/option"
 	"google.golang.org/api/people/v1"
 
 	// OAuth2
@@ -34,10 +36,10 @@ import (
 const (
 	// gRPC server port
 	grpcPort = ":50051"
+	// Base URL of the local HTTP server handling the OAuth2 flow
+	oauthServerURL = "http://localhost:8080"
 	// OAuth2 redirect URL for the local server
-	oauthRedirectURL = "http://localhost:8080/oauth2callback"
-	// Token cache file for simplicity (NOT for production)
-	tokenCacheFile = "token.json"
+	oauthRedirectURL = oauthServerURL + "/oauth2callback"
 	// Credential file name
 	credentialsFile = "credentials.json"
 )
@@ -58,23 +60,28 @@ type Config struct {
 // global variables for simplicity in this single file example
 var (
 	googleOAuthConfig *oauth2.Config
-	// tokenStore stores tokens per user or session in a real app.
-	// For this example, we'll manage a single token in the context of gRPC calls.
-	// In a real app, you'd load/save this from a database based on a user ID.
+	// userTokens stores the OAuth tokens of each authorized user.
+	userTokens TokenStore
+	// authorizations tracks the authorization links handed out to users.
+	authorizations *authorizationFlows
 )
 
-// Helper to get an OAuth2 token from the request, or initiate a new flow
+// Helper to get an OAuth2 token for the user of the request, from the tokens
+// stored when they authorized access. Requests without a user ID are
+// rejected; the deprecated auth_tokens are ignored, since they would let any
+// client act with whatever tokens it holds.
 func getTokenFromRequest(ctx context.Context, commonReq *pb.CommonRequest) (*oauth2.Token, error) {
-	if commonReq == nil || commonReq.AuthTokens == nil {
-		return nil, status.Errorf(codes.Unauthenticated, "No OAuth tokens provided in request.")
+	userID := commonReq.GetUserId()
+	if userID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "No user ID provided in request.")
 	}
-
-	authTokens := commonReq.AuthTokens
-	tok := &oauth2.Token{
-		AccessToken:  authTokens.AccessToken,
-		RefreshToken: authTokens.RefreshToken,
-		TokenType:    authTokens.TokenType,
-		Expiry:       time.Unix(authTokens.ExpiryUnix, 0),
+	tok, err := userTokens.Get(userID)
+	switch {
+	case errors.Is(err, errTokenNotFound):
+		return nil, authorizationError(codes.Unauthenticated, userID, fmt.Sprintf("No Google account is authorized for user %s.", userID), nil)
+	case err != nil:
+		log.Printf("Unable to get OAuth token of user %s: %v", userID, err)
+		return nil, status.Errorf(codes.Internal, "Unable to get stored OAuth token: %v", err)
 	}
 
 	// Create a token source with the provided token.
@@ -90,20 +97,36 @@ func getTokenFromRequest(ctx context.Context, commonReq *pb.CommonRequest) (*oau
 		return nil, status.Errorf(codes.Unauthenticated, "Failed to get fresh token: %v. Please re-authenticate.", err)
 	}
 
-	// If the token was refreshed, update the client with the new token details
-	if freshTok.AccessToken != tok.AccessToken || freshTok.Expiry.Unix() != tok.Expiry.Unix() {
+	refreshed := freshTok.AccessToken != tok.AccessToken || freshTok.Expiry.Unix() != tok.Expiry.Unix()
+	if refreshed {
 		log.Println("Token was refreshed.")
-		// In a real application, you would persist freshTok.RefreshToken and other details
-		// associated with the user who made the original request.
-		// For this example, we'll just return the fresh token and assume the client
-		// (e.g., the Multiple MCP Client) will handle persisting it if needed.
+		if err := userTokens.Put(userID, freshTok); err != nil {
+			log.Printf("Unable to store OAuth token for user %s: %v", userID, err)
+		}
 	}
 
 	return freshTok, nil
 }
 
-// Function to handle the OAuth2 callback (for initial token acquisition)
+// Function to handle the OAuth2 callback (for initial token acquisition).
+// The state must be one issued by StartAuthorization; it names the user the
+// tokens are stored for and the PKCE verifier of the code.
 func handleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
+	userID, verifier, err := authorizations.Finish(r.URL.Query().Get("state"))
+	switch {
+	case errors.Is(err, errExpiredAuthState):
+		http.Error(w, "This authorization link has expired or was already used. Ask the assistant for a new one.", http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("Invalid state in OAuth2 callback: %v", err)
+		http.Error(w, "Invalid authorization state.", http.StatusBadRequest)
+		return
+	}
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		log.Printf("Authorization for user %s was not granted: %s", userID, errParam)
+		http.Error(w, "Authorization was not granted. Ask the assistant for a new link to try again.", http.StatusForbidden)
+		return
+	}
 	code := r.URL.Query().Get("code")
 	if code == "" {
 		log.Printf("No 'code' parameter in OAuth2 callback: %v", r.URL.Query())
@@ -111,28 +134,29 @@ func handleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tok, err := googleOAuthConfig.Exchange(r.Context(), code)
+	tok, err := googleOAuthConfig.Exchange(r.Context(), code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		log.Printf("Unable to retrieve token from web: %v", err)
 		http.Error(w, fmt.Sprintf("Unable to retrieve token from web: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// For simplicity, save token to a file. In a real app, this would be persisted securely.
-	b, err := json.MarshalIndent(tok, "", "  ")
+	// The link may have been forwarded, so the tokens are only stored once
+	// the user it was issued for confirms them from their own chat.
+	confirmation, err := authorizations.Hold(userID, tok)
 	if err != nil {
-		log.Printf("Unable to marshal token: %v", err)
-		http.Error(w, "Internal server error.", http.StatusInternalServerError)
-		return
-	}
-	if err := ioutil.WriteFile(tokenCacheFile, b, 0600); err != nil {
-		log.Printf("Unable to cache OAuth token: %v", err)
+		log.Printf("Unable to hold OAuth token for user %s: %v", userID, err)
 		http.Error(w, "Internal server error.", http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Fprintf(w, "Authentication successful! Your tokens have been saved to %s. You can now make gRPC calls.", tokenCacheFile)
-	log.Println("OAuth token saved to token.json")
+	account := "your Google account"
+	if email := idTokenEmail(tok); email != "" {
+		account = email
+	}
+	fmt.Fprintf(w, "Almost done! To connect %s, send this message to the assistant from your own chat within %d minutes:\n\n/confirm %s\n\nIf someone else sent you this link, do not share the code with them: it would give them access to your account.",
+		account, int(authorizationTTL.Minutes()), confirmation)
+	log.Printf("OAuth token of user %s waiting for confirmation", userID)
 }
 
 // ====================================================================
@@ -148,41 +172,20 @@ func (s *calendarServer) ListEvents(ctx context.Context, req *pb.ListEventsReque
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, calendar.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve Calendar client: %v", err)
 	}
 
 	t := time.Now().Add(-24 * time.Hour).Format(time.RFC3339) // Events from yesterday
-	events, err := srv.Events.List(req.CalendarId).ShowDeleted(false).SingleEvents(true).TimeMin(t).MaxResults(int64(req.MaxResults)).OrderBy("startTime").Do()
+	events, err := srv.Events.List(req.CalendarId).ShowDeleted(false).SingleEvents(true).TimeMin(t).MaxResults(int64(req.MaxResults)).OrderBy("startTime").Context(ctx).Do()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to retrieve calendar events: %v", err)
+		return nil, googleAPIError(err, "Unable to retrieve calendar events")
 	}
 
 	var pbEvents []*pb.Event
 	for _, item := range events.Items {
-		start := ""
-		if item.Start.DateTime != "" {
-			start = item.Start.DateTime
-		} else {
-			start = item.Start.Date
-		}
-		end := ""
-		if item.End.DateTime != "" {
-			end = item.End.DateTime
-		} else {
-			end = item.End.Date
-		}
-
-		pbEvents = append(pbEvents, &pb.Event{
-			Id:          item.Id,
-			Summary:     item.Summary,
-			Description: item.Description,
-			StartTime:   start,
-			EndTime:     end,
-			HtmlLink:    item.HtmlLink,
-		})
+		pbEvents = append(pbEvents, eventToProto(item))
 	}
 
 	return &pb.ListEventsResponse{
@@ -197,8 +200,7 @@ func (s *calendarServer) CreateEvent(ctx context.Context, req *pb.CreateEventReq
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, calendar.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve Calendar client: %v", err)
 	}
@@ -215,10 +217,13 @@ func (s *calendarServer) CreateEvent(ctx context.Context, req *pb.CreateEventReq
 			TimeZone: req.TimeZone,
 		},
 	}
+	for _, email := range req.Attendees {
+		event.Attendees = append(event.Attendees, &calendar.EventAttendee{Email: email})
+	}
 
-	newEvent, err := srv.Events.Insert(req.CalendarId, event).Do()
+	newEvent, err := srv.Events.Insert(req.CalendarId, event).Context(ctx).Do()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to create calendar event: %v", err)
+		return nil, googleAPIError(err, "Unable to create calendar event")
 	}
 
 	pbEvent := &pb.Event{
@@ -241,6 +246,18 @@ func (s *calendarServer) CreateEvent(ctx context.Context, req *pb.CreateEventReq
 // ====================================================================
 type gmailServer struct {
 	pb.UnimplementedGmailServiceServer
+	scheduled *scheduledEmailStore
+	// httpClient is used for requests outside the Gmail API, such as one-click
+	// unsubscribes. Nil means a default client.
+	httpClient *http.Client
+}
+
+// newRawMessage builds a Gmail message with a minimal MIME encoding of the
+// email. The subject is RFC 2047 encoded, so it cannot break out of its header.
+func newRawMessage(to, subject, body string) *gmail.Message {
+	subject = mime.QEncoding.Encode("utf-8", subject)
+	mimeMessage := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", to, subject, body))
+	return &gmail.Message{Raw: base64.URLEncoding.EncodeToString(mimeMessage)}
 }
 
 func (s *gmailServer) SendEmail(ctx context.Context, req *pb.SendEmailRequest) (*pb.SendEmailResponse, error) {
@@ -249,19 +266,16 @@ func (s *gmailServer) SendEmail(ctx context.Context, req *pb.SendEmailRequest) (
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
 	}
 
-	var message gmail.Message
-	mimeMessage := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", req.To, req.Subject, req.Body))
-	message.Raw = base64.URLEncoding.EncodeToString(mimeMessage)
+	message := newRawMessage(req.To, req.Subject, req.Body)
 
-	_, err = srv.Users.Messages.Send("me", &message).Do()
+	_, err = srv.Users.Messages.Send("me", message).Context(ctx).Do()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to send email: %v", err)
+		return nil, googleAPIError(err, "Unable to send email")
 	}
 
 	return &pb.SendEmailResponse{
@@ -276,8 +290,7 @@ func (s *gmailServer) ListMessages(ctx context.Context, req *pb.ListMessagesRequ
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
 	}
@@ -290,9 +303,9 @@ func (s *gmailServer) ListMessages(ctx context.Context, req *pb.ListMessagesRequ
 		call.Q(req.Query)
 	}
 
-	msgs, err := call.Do()
+	msgs, err := call.Context(ctx).Do()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to list messages: %v", err)
+		return nil, googleAPIError(err, "Unable to list messages")
 	}
 
 	var pbMessages []*pb.Message
@@ -316,15 +329,14 @@ func (s *gmailServer) GetMessage(ctx context.Context, req *pb.GetMessageRequest)
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
 	}
 
-	msg, err := srv.Users.Messages.Get("me", req.MessageId).Format("full").Do()
+	msg, err := srv.Users.Messages.Get("me", req.MessageId).Format("full").Context(ctx).Do()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to get message: %v", err)
+		return nil, googleAPIError(err, "Unable to get message")
 	}
 
 	// Extract headers
@@ -381,40 +393,37 @@ func (s *contactsServer) ListConnections(ctx context.Context, req *pb.ListConnec
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := people.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
 	}
 
-	call := srv.People.Connections.List("people/me").
-		PersonFields("names,emailAddresses,phoneNumbers").
-		PageSize(int64(req.PageSize))
-
-	connections, err := call.Do()
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to list connections: %v", err)
-	}
-
-	var pbPeople []*pb.Person
-	for _, person := range connections.Connections {
-		var name, email, phone string
-		if len(person.Names) > 0 {
-			name = person.Names[0].DisplayName
+	var connections []*people.Person
+	if req.Group != "" {
+		// connections.list cannot filter by group, so read the group's members instead.
+		group, err := resolveContactGroup(ctx, srv, req.Group)
+		if err != nil {
+			return nil, err
 		}
-		if len(person.EmailAddresses) > 0 {
-			email = person.EmailAddresses[0].Value
+		connections, err = listGroupMembers(ctx, srv, group, int(req.PageSize))
+		if err != nil {
+			return nil, err
 		}
-		if len(person.PhoneNumbers) > 0 {
-			phone = person.PhoneNumbers[0].Value
+	} else {
+		call := srv.People.Connections.List("people/me").
+			PersonFields(personReadFields).
+			PageSize(int64(req.PageSize))
+
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to list connections")
 		}
+		connections = resp.Connections
+	}
 
-		pbPeople = append(pbPeople, &pb.Person{
-			ResourceName: person.ResourceName,
-			DisplayName:  name,
-			Email:        email,
-			PhoneNumber:  phone,
-		})
+	var pbPeople []*pb.Person
+	for _, person := range connections {
+		pbPeople = append(pbPeople, personToProto(person))
 	}
 
 	return &pb.ListConnectionsResponse{
@@ -429,73 +438,52 @@ func (s *contactsServer) CreateContact(ctx context.Context, req *pb.CreateContac
 		return nil, err
 	}
 
-	client := googleOAuthConfig.Client(ctx, tok)
-	srv, err := people.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
 	}
 
-	contact := &people.Person{
-		Names: []*people.Name{
-			{
-				DisplayName: req.DisplayName,
-			},
-		},
+	contact, err := personFromProto(createRequestToPerson(req))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid contact: %v", err)
 	}
-	if req.Email != "" {
-		contact.EmailAddresses = []*people.EmailAddress{
-			{
-				Value: req.Email,
-			},
-		}
-	}
-	if req.PhoneNumber != "" {
-		contact.PhoneNumbers = []*people.PhoneNumber{
-			{
-				Value: req.PhoneNumber,
-			},
-		}
+	if err := normalizePersonPhones(contact, requestPhoneRegion(req.Common)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid contact: %v", err)
 	}
 
-	createdPerson, err := srv.People.CreateContact(contact).Do()
+	duplicates, err := findPossibleDuplicates(ctx, srv, contact)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Unable to create contact: %v", err)
+		return nil, googleAPIError(err, "Unable to check for duplicate contacts")
 	}
-
-	// Ejemplo de cómo manejar el acceso seguro:
-	var email string
-	if createdPerson.EmailAddresses != nil && len(createdPerson.EmailAddresses) > 0 {
-		email = createdPerson.EmailAddresses[0].Value
-	} else {
-		email = "" // O maneja el caso de que no haya email
+	var pbDuplicates []*pb.Person
+	var duplicateNames []string
+	for _, d := range duplicates {
+		pbDuplicate := personToProto(d)
+		pbDuplicates = append(pbDuplicates, pbDuplicate)
+		duplicateNames = append(duplicateNames, fmt.Sprintf("%s (%s)", pbDuplicate.DisplayName, pbDuplicate.ResourceName))
 	}
-
-	var phoneNumber string
-	if createdPerson.PhoneNumbers != nil && len(createdPerson.PhoneNumbers) > 0 {
-		phoneNumber = createdPerson.PhoneNumbers[0].Value
-	} else {
-		phoneNumber = "" // O maneja el caso de que no haya número de teléfono
+	if len(duplicates) > 0 && req.FailOnDuplicate {
+		return nil, status.Errorf(codes.AlreadyExists, "Similar contacts already exist: %s.", strings.Join(duplicateNames, ", "))
 	}
 
-	// Para DisplayName, el Names[0].DisplayName debería estar presente si se envió en la creación
-	// pero siempre es buena práctica verificar:
-	var displayName string
-	if createdPerson.Names != nil && len(createdPerson.Names) > 0 {
-		displayName = createdPerson.Names[0].DisplayName
-	} else {
-		displayName = req.DisplayName // Usa el que se envió en la solicitud si no hay DisplayName de vuelta
+	createdPerson, err := srv.People.CreateContact(contact).PersonFields(personReadFields).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to create contact")
 	}
 
-	pbPerson := &pb.Person{
-		DisplayName:  displayName,
-		Email:        email,       // Usa la variable segura
-		PhoneNumber:  phoneNumber, // Usa la variable segura
-		ResourceName: createdPerson.ResourceName,
+	pbPerson := personToProto(createdPerson)
+	if pbPerson.DisplayName == "" {
+		pbPerson.DisplayName = req.DisplayName // Names are computed asynchronously and may be missing right after creation
 	}
 
+	message := "Contact created successfully."
+	if len(duplicates) > 0 {
+		message = fmt.Sprintf("Contact created successfully, but similar contacts already exist: %s.", strings.Join(duplicateNames, ", "))
+	}
 	return &pb.CreateContactResponse{
-		Common:         &pb.CommonResponse{Status: "OK", Message: "Contact created successfully."},
-		CreatedContact: pbPerson,
+		Common:             &pb.CommonResponse{Status: "OK", Message: message},
+		CreatedContact:     pbPerson,
+		PossibleDuplicates: pbDuplicates,
 	}, nil
 }
 
@@ -520,25 +508,54 @@ func main() {
 		ClientID:     cfg.Web.ClientID,
 		ClientSecret: cfg.Web.ClientSecret,
 		RedirectURL:  oauthRedirectURL,
-		Scopes: []string{
-			calendar.CalendarEventsScope, // Full access to Calendar events
-			gmail.GmailModifyScope,       // Full access to Gmail messages, including sending
-			people.ContactsScope,         // Full access to Contacts
-		},
+		// Only identify the account; the scopes of each service in
+		// serviceScopes are requested the first time it is used.
+		Scopes:   baseScopes,
 		Endpoint: google.Endpoint,
 	}
 
-	// Start a simple HTTP server for OAuth2 callback
+	tokenKey, oldTokenKeys, err := loadTokenKeys()
+	if err != nil {
+		log.Fatalf("Invalid token encryption configuration: %v", err)
+	}
+	userTokens, err = newEncryptedTokenStore(tokenStoreFile, tokenKey, oldTokenKeys)
+	if err != nil {
+		log.Fatalf("Unable to load stored OAuth tokens: %v", err)
+	}
+
+	authorizations, err = newAuthorizationFlows()
+	if err != nil {
+		log.Fatalf("Unable to set up the authorization flow: %v", err)
+	}
+
+	// Start a simple HTTP server for the OAuth2 flow
 	go func() {
 		http.HandleFunc("/oauth2callback", handleOAuth2Callback)
 		log.Printf("Starting OAuth2 callback handler on %s...", oauthRedirectURL)
 		log.Fatal(http.ListenAndServe(":8080", nil)) // Listen on port 8080 for OAuth callback
 	}()
 
-	// Print the URL to authorize
-	authURL := googleOAuthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce)
-	log.Printf("Go to the following link in your browser to authorize your Google account:\n%s", authURL)
-	log.Println("After authorization, the tokens will be saved to token.json in the current directory.")
+	log.Println("Users get a link to authorize their Google account from AuthService.StartAuthorization.")
+	log.Printf("After authorization, the tokens are stored encrypted per user in %s in the current directory.", tokenStoreFile)
+
+	if err := loadDefaultPhoneRegion(); err != nil {
+		log.Fatalf("Invalid phone number configuration: %v", err)
+	}
+	if err := loadRetryPolicies(); err != nil {
+		log.Fatalf("Invalid retry configuration: %v", err)
+	}
+
+	// Load the scheduled email queue and start sending due emails in the background
+	scheduledEmails, err := newScheduledEmailStore(scheduledEmailsFile)
+	if err != nil {
+		log.Fatalf("Unable to load scheduled emails: %v", err)
+	}
+	scheduler := &emailScheduler{
+		store:    scheduledEmails,
+		interval: schedulerInterval,
+		send:     sendScheduledEmail,
+	}
+	go scheduler.Run(context.Background())
 
 	// Set up gRPC server
 	lis, err := net.Listen("tcp", grpcPort)
@@ -546,10 +563,19 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
+	limiter := newRateLimiter()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(limiter.unaryInterceptor, scopeInterceptor),
+		grpc.ChainStreamInterceptor(limiter.streamInterceptor),
+	)
 	pb.RegisterCalendarServiceServer(s, &calendarServer{})
-	pb.RegisterGmailServiceServer(s, &gmailServer{})
+	pb.RegisterTasksServiceServer(s, &tasksServer{})
+	pb.RegisterGmailServiceServer(s, &gmailServer{scheduled: scheduledEmails})
 	pb.RegisterContactsServiceServer(s, &contactsServer{})
+	pb.RegisterDriveServiceServer(s, &driveServer{})
+	pb.RegisterDocsServiceServer(s, &docsServer{})
+	pb.RegisterSheetsServiceServer(s, &sheetsServer{})
+	pb.RegisterAuthServiceServer(s, &authServer{httpClient: http.DefaultClient, scheduled: scheduledEmails})
 
 	log.Printf("gRPC server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
@@ -0,0 +1,256 @@
+// mcp_services/meeting_briefing.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/people/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	defaultBriefingThreadsPerAttendee = 3
+	maxBriefingThreadsPerAttendee     = 10
+	defaultBriefingLookbackDays       = 90
+	// Attendees beyond this many are listed without contacts or threads
+	maxBriefedAttendees = 15
+	// Number of upcoming events searched for one with attendees
+	upcomingEventsSearched = 10
+)
+
+// eventToProto converts a Calendar event, using the date of all-day events
+// as their start and end times.
+func eventToProto(item *calendar.Event) *pb.Event {
+	start, end := "", ""
+	if item.Start != nil {
+		start = item.Start.DateTime
+		if start == "" {
+			start = item.Start.Date
+		}
+	}
+	if item.End != nil {
+		end = item.End.DateTime
+		if end == "" {
+			end = item.End.Date
+		}
+	}
+	return &pb.Event{
+		Id:          item.Id,
+		Summary:     item.Summary,
+		Description: item.Description,
+		StartTime:   start,
+		EndTime:     end,
+		HtmlLink:    item.HtmlLink,
+	}
+}
+
+// hasOtherAttendees reports whether someone besides the user is invited.
+func hasOtherAttendees(event *calendar.Event) bool {
+	for _, a := range event.Attendees {
+		if !a.Self && !a.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// nextEventWithAttendees returns the next upcoming event that has attendees
+// other than the user.
+func nextEventWithAttendees(ctx context.Context, srv *calendar.Service, calendarID string) (*calendar.Event, error) {
+	events, err := srv.Events.List(calendarID).
+		TimeMin(time.Now().Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(upcomingEventsSearched).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to retrieve calendar events")
+	}
+	for _, e := range events.Items {
+		if hasOtherAttendees(e) {
+			return e, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "None of the next %d events has other attendees.", upcomingEventsSearched)
+}
+
+// recentThreadsWith lists the latest email threads exchanged with an address.
+func recentThreadsWith(ctx context.Context, srv *gmail.Service, email string, lookbackDays, limit int) ([]*pb.EmailThreadSummary, error) {
+	query := fmt.Sprintf("{from:%s to:%s cc:%s} newer_than:%dd", email, email, email, lookbackDays)
+	threads, err := srv.Users.Threads.List("me").Q(query).MaxResults(int64(limit)).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []*pb.EmailThreadSummary
+	for _, t := range threads.Threads {
+		thread, err := srv.Users.Threads.Get("me", t.Id).Format("metadata").MetadataHeaders("Subject", "From", "Date").Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		summary := &pb.EmailThreadSummary{
+			ThreadId:     thread.Id,
+			Snippet:      t.Snippet,
+			MessageCount: int32(len(thread.Messages)),
+		}
+		if len(thread.Messages) > 0 {
+			latest := thread.Messages[len(thread.Messages)-1]
+			summary.Snippet = latest.Snippet
+			if latest.Payload != nil {
+				for _, h := range latest.Payload.Headers {
+					switch h.Name {
+					case "Subject":
+						summary.Subject = h.Value
+					case "From":
+						summary.From = h.Value
+					case "Date":
+						summary.Date = h.Value
+					}
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (s *calendarServer) GetMeetingBriefing(ctx context.Context, req *pb.GetMeetingBriefingRequest) (*pb.GetMeetingBriefingResponse, error) {
+	calendarID := req.CalendarId
+	if calendarID == "" {
+		calendarID = "primary"
+	}
+	threadsPerAttendee := int(req.MaxThreadsPerAttendee)
+	if threadsPerAttendee <= 0 {
+		threadsPerAttendee = defaultBriefingThreadsPerAttendee
+	}
+	if threadsPerAttendee > maxBriefingThreadsPerAttendee {
+		threadsPerAttendee = maxBriefingThreadsPerAttendee
+	}
+	lookbackDays := int(req.LookbackDays)
+	if lookbackDays <= 0 {
+		lookbackDays = defaultBriefingLookbackDays
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	calendarSrv, err := googleService(ctx, req.Common.GetUserId(), tok, calendar.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Calendar client: %v", err)
+	}
+	peopleSrv, err := googleService(ctx, req.Common.GetUserId(), tok, people.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve People client: %v", err)
+	}
+	gmailSrv, err := googleService(ctx, req.Common.GetUserId(), tok, gmail.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Gmail client: %v", err)
+	}
+
+	var event *calendar.Event
+	if req.EventId != "" {
+		event, err = calendarSrv.Events.Get(calendarID, req.EventId).Context(ctx).Do()
+		if err != nil {
+			return nil, googleAPIError(err, "Unable to get calendar event")
+		}
+	} else {
+		event, err = nextEventWithAttendees(ctx, calendarSrv, calendarID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Everything below is best effort: a failing lookup becomes a warning
+	// instead of failing the whole briefing.
+	var warnings []string
+
+	if err := warmUpContactSearch(ctx, peopleSrv); err != nil {
+		warnings = append(warnings, fmt.Sprintf("Could not prepare the contact search: %v", err))
+	}
+
+	var attendees []*pb.AttendeeBriefing
+	briefed := 0
+	for _, a := range event.Attendees {
+		if a.Self || a.Resource {
+			continue
+		}
+		attendee := &pb.AttendeeBriefing{
+			Email:          a.Email,
+			DisplayName:    a.DisplayName,
+			ResponseStatus: a.ResponseStatus,
+			Organizer:      a.Organizer,
+		}
+		attendees = append(attendees, attendee)
+		if briefed == maxBriefedAttendees {
+			continue
+		}
+		briefed++
+
+		contact, err := findContactByEmail(ctx, peopleSrv, []*people.EmailAddress{{Value: a.Email}})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Could not look up the contact of %s: %v", a.Email, err))
+		} else if contact != nil {
+			attendee.Contact = personToProto(contact)
+			if attendee.DisplayName == "" {
+				attendee.DisplayName = attendee.Contact.DisplayName
+			}
+		}
+
+		threads, err := recentThreadsWith(ctx, gmailSrv, a.Email, lookbackDays, threadsPerAttendee)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Could not list the email threads with %s: %v", a.Email, err))
+		} else {
+			attendee.RecentThreads = threads
+		}
+	}
+	if briefed < len(attendees) {
+		warnings = append(warnings, fmt.Sprintf("Only the first %d of %d attendees were looked up.", briefed, len(attendees)))
+	}
+
+	var documents []*pb.DriveFile
+	var driveSrv *drive.Service
+	if len(event.Attachments) > 0 {
+		driveSrv, err = googleService(ctx, req.Common.GetUserId(), tok, drive.NewService)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Unable to retrieve Drive client: %v", err)
+		}
+	}
+	for _, att := range event.Attachments {
+		fallback := &pb.DriveFile{Id: att.FileId, Name: att.Title, MimeType: att.MimeType, WebViewLink: att.FileUrl}
+		if att.FileId == "" {
+			documents = append(documents, fallback)
+			continue
+		}
+		f, err := driveSrv.Files.Get(att.FileId).Fields(driveFileFields).Context(ctx).Do()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Could not get the details of the attached file %q: %v", att.Title, err))
+			documents = append(documents, fallback)
+			continue
+		}
+		documents = append(documents, driveFileToProto(f))
+	}
+
+	message := "Meeting briefing prepared successfully."
+	if len(warnings) > 0 {
+		message = "Meeting briefing prepared with some missing information; see the warnings."
+	}
+	return &pb.GetMeetingBriefingResponse{
+		Common:    &pb.CommonResponse{Status: "OK", Message: message},
+		Event:     eventToProto(event),
+		Location:  event.Location,
+		Attendees: attendees,
+		Documents: documents,
+		Warnings:  warnings,
+	}, nil
+}
@@ -0,0 +1,93 @@
+// mcp_services/phone_numbers.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+	"google.golang.org/api/people/v1"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Environment variable holding the region assumed for phone numbers without
+// a country code when the request does not set one
+const defaultPhoneRegionEnv = "DEFAULT_PHONE_REGION"
+
+// defaultPhoneRegion is read from defaultPhoneRegionEnv at startup.
+var defaultPhoneRegion string
+
+// requestPhoneRegion returns the region used to interpret the phone numbers
+// of a request.
+func requestPhoneRegion(commonReq *pb.CommonRequest) string {
+	if commonReq != nil && commonReq.DefaultPhoneRegion != "" {
+		return strings.ToUpper(commonReq.DefaultPhoneRegion)
+	}
+	return defaultPhoneRegion
+}
+
+// loadDefaultPhoneRegion reads and validates defaultPhoneRegionEnv.
+func loadDefaultPhoneRegion() error {
+	region := strings.ToUpper(strings.TrimSpace(os.Getenv(defaultPhoneRegionEnv)))
+	if region == "" {
+		return nil
+	}
+	if _, ok := libphonenumber.GetSupportedRegions()[region]; !ok {
+		return fmt.Errorf("%s=%q is not a supported region code", defaultPhoneRegionEnv, region)
+	}
+	defaultPhoneRegion = region
+	return nil
+}
+
+// normalizePhoneNumber validates a phone number and returns it in E.164
+// form. Numbers without a country code are interpreted in region.
+func normalizePhoneNumber(phone, region string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", fmt.Errorf("empty phone number")
+	}
+	// "00" is the international prefix in most regions, and Gemini often uses it.
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !strings.HasPrefix(phone, "+") && region == "" {
+		return "", fmt.Errorf("phone number %q has no country code and no default region is configured", phone)
+	}
+	num, err := libphonenumber.Parse(phone, region)
+	if err != nil {
+		return "", fmt.Errorf("invalid phone number %q: %v", phone, err)
+	}
+	if !libphonenumber.IsValidNumber(num) {
+		return "", fmt.Errorf("invalid phone number %q", phone)
+	}
+	return libphonenumber.Format(num, libphonenumber.E164), nil
+}
+
+// normalizePersonPhones replaces every phone number of a person with its
+// E.164 form, failing on the first invalid one.
+func normalizePersonPhones(person *people.Person, region string) error {
+	for _, p := range person.PhoneNumbers {
+		normalized, err := normalizePhoneNumber(p.Value, region)
+		if err != nil {
+			return err
+		}
+		p.Value = normalized
+	}
+	return nil
+}
+
+// storedPhoneE164 returns the E.164 form of a stored phone number, preferring
+// the canonical form computed by Google. It returns "" if the number is not
+// valid.
+func storedPhoneE164(p *people.PhoneNumber) string {
+	if p.CanonicalForm != "" {
+		return p.CanonicalForm
+	}
+	normalized, err := normalizePhoneNumber(p.Value, defaultPhoneRegion)
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
@@ -0,0 +1,324 @@
+// mcp_services/rate_limit.go
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// How often idle buckets and past daily counts are dropped
+	rateLimitSweepInterval = 10 * time.Minute
+	// How long an email cannot be sent or scheduled again with the same
+	// recipients, subject and body
+	duplicateSendWindow = 10 * time.Minute
+)
+
+// bucketLimit is a token bucket: up to burst calls at once, refilled at
+// perMinute calls per minute.
+type bucketLimit struct {
+	perMinute float64
+	burst     int
+}
+
+// Limit on all the calls of a user
+var userLimit = bucketLimit{perMinute: 120, burst: 30}
+
+// methodLimits holds the limits of methods that act on the user's behalf
+// beyond their account, so a model stuck in a loop cannot repeat them.
+var methodLimits = map[string]bucketLimit{
+	"/mcp.GmailService/SendEmail":             {perMinute: 3, burst: 3},
+	"/mcp.GmailService/ScheduleEmail":         {perMinute: 3, burst: 3},
+	"/mcp.GmailService/UnsubscribeFromSender": {perMinute: 5, burst: 5},
+	"/mcp.GmailService/BatchModifyByQuery":    {perMinute: 5, burst: 5},
+	"/mcp.DriveService/ShareFile":             {perMinute: 5, burst: 5},
+}
+
+// dailyCaps holds the most calls a user can make to methods that send
+// something in a day, server local time. Only calls that succeed count.
+// Counts are kept in memory and start over when the server restarts.
+var dailyCaps = map[string]int{
+	"/mcp.GmailService/SendEmail":             100,
+	"/mcp.GmailService/ScheduleEmail":         100,
+	"/mcp.GmailService/UnsubscribeFromSender": 50,
+	"/mcp.DriveService/ShareFile":             100,
+}
+
+type rateLimitKey struct {
+	user   string
+	method string // "" for the limit on all the calls of user
+}
+
+type dailyCount struct {
+	day   string // YYYY-MM-DD
+	count int
+}
+
+// rateLimiter enforces userLimit, methodLimits and dailyCaps per user, and
+// rejects emails repeated within duplicateSendWindow.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[rateLimitKey]*rate.Limiter
+	daily     map[rateLimitKey]*dailyCount
+	sent      map[[sha256.Size]byte]time.Time // When each recent email was sent, by emailDigest
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[rateLimitKey]*rate.Limiter),
+		daily:     make(map[rateLimitKey]*dailyCount),
+		sent:      make(map[[sha256.Size]byte]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *rateLimiter) bucket(key rateLimitKey, limit bucketLimit) *rate.Limiter {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = rate.NewLimiter(rate.Limit(limit.perMinute/60), limit.burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// allow records a call of user to method, or returns a ResourceExhausted
+// error saying when to try again if it goes over a limit. Rejected calls do
+// not use up any limit. The call counts towards the daily cap of method
+// until refund is called for it.
+func (l *rateLimiter) allow(user, method string, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	day := now.Format("2006-01-02")
+	dailyKey := rateLimitKey{user: user, method: method}
+	if limit, ok := dailyCaps[method]; ok {
+		if c := l.daily[dailyKey]; c != nil && c.day == day && c.count >= limit {
+			y, m, d := now.Date()
+			tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+			return rateLimitError(tomorrow.Sub(now),
+				fmt.Sprintf("Daily limit of %d calls to %s reached.", limit, method))
+		}
+	}
+
+	var reservations []*rate.Reservation
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	check := func(key rateLimitKey, limit bucketLimit, message string) error {
+		r := l.bucket(key, limit).ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if delay := r.DelayFrom(now); delay > 0 {
+			cancel()
+			return rateLimitError(delay, message)
+		}
+		return nil
+	}
+	if err := check(rateLimitKey{user: user}, userLimit, "Too many requests."); err != nil {
+		return err
+	}
+	if limit, ok := methodLimits[method]; ok {
+		if err := check(rateLimitKey{user: user, method: method}, limit,
+			fmt.Sprintf("Too many calls to %s.", method)); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := dailyCaps[method]; ok {
+		c := l.daily[dailyKey]
+		if c == nil || c.day != day {
+			c = &dailyCount{day: day}
+			l.daily[dailyKey] = c
+		}
+		c.count++
+	}
+	return nil
+}
+
+// refund takes back from the daily cap of method a call of user allowed at
+// now that failed.
+func (l *rateLimiter) refund(user, method string, now time.Time) {
+	if _, ok := dailyCaps[method]; !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c := l.daily[rateLimitKey{user: user, method: method}]; c != nil && c.day == now.Format("2006-01-02") && c.count > 0 {
+		c.count--
+	}
+}
+
+// emailDigest identifies the email a call to SendEmail or ScheduleEmail
+// sends for user by its recipients, subject and body. Recipients are
+// compared as a set of addresses. ok is false for other calls.
+func emailDigest(user string, req interface{}) (digest [sha256.Size]byte, ok bool) {
+	var method, to, subject, body string
+	switch r := req.(type) {
+	case *pb.SendEmailRequest:
+		method, to, subject, body = "send", r.To, r.Subject, r.Body
+	case *pb.ScheduleEmailRequest:
+		method, to, subject, body = "schedule", r.To, r.Subject, r.Body
+	default:
+		return digest, false
+	}
+
+	var recipients []string
+	if addrs, err := mail.ParseAddressList(to); err == nil {
+		for _, a := range addrs {
+			recipients = append(recipients, strings.ToLower(a.Address))
+		}
+	} else {
+		// The handler rejects it; compare it as given.
+		recipients = []string{strings.ToLower(strings.TrimSpace(to))}
+	}
+	sort.Strings(recipients)
+
+	bodySum := sha256.Sum256([]byte(body))
+	fields := []string{user, method, strings.Join(recipients, ","), subject, string(bodySum[:])}
+	return sha256.Sum256([]byte(strings.Join(fields, "\x00"))), true
+}
+
+// reserveEmail records that user is sending the email of req at now, or
+// returns an AlreadyExists error if the same email was sent within
+// duplicateSendWindow, so a model repeating a call cannot send it twice.
+// ok is false for calls that send no email.
+func (l *rateLimiter) reserveEmail(user string, req interface{}, now time.Time) (digest [sha256.Size]byte, ok bool, err error) {
+	digest, ok = emailDigest(user, req)
+	if !ok {
+		return digest, false, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at, found := l.sent[digest]; found && now.Sub(at) < duplicateSendWindow {
+		return digest, false, status.Errorf(codes.AlreadyExists,
+			"An identical email to the same recipients was already sent %v ago, so it was not sent again.", now.Sub(at).Round(time.Second))
+	}
+	l.sent[digest] = now
+	return digest, true, nil
+}
+
+// releaseEmail forgets an email reserved with reserveEmail whose call failed,
+// so it can be sent again.
+func (l *rateLimiter) releaseEmail(digest [sha256.Size]byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.sent, digest)
+}
+
+// sweep drops buckets that are full again, as a new one behaves the same,
+// daily counts of previous days and emails sent before duplicateSendWindow.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.TokensAt(now) >= float64(b.Burst()) {
+			delete(l.buckets, key)
+		}
+	}
+	day := now.Format("2006-01-02")
+	for key, c := range l.daily {
+		if c.day != day {
+			delete(l.daily, key)
+		}
+	}
+	for digest, at := range l.sent {
+		if now.Sub(at) >= duplicateSendWindow {
+			delete(l.sent, digest)
+		}
+	}
+}
+
+// rateLimitError returns a ResourceExhausted error with a RetryInfo detail.
+func rateLimitError(delay time.Duration, message string) error {
+	delay = delay.Round(time.Second)
+	if delay < time.Second {
+		delay = time.Second
+	}
+	st := status.Newf(codes.ResourceExhausted, "%s Try again in %v.", message, delay)
+	return withDetails(st, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+}
+
+// callerID returns the user a request is limited as: its user ID, or the
+// host of the client for requests without one. The
+// port is left out, as each new connection of a client gets another one.
+func callerID(ctx context.Context, req interface{}) string {
+	if withCommon, ok := req.(interface{ GetCommon() *pb.CommonRequest }); ok {
+		if userID := withCommon.GetCommon().GetUserId(); userID != "" {
+			return userID
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		return "peer:" + addr
+	}
+	return ""
+}
+
+// unaryInterceptor rejects calls over the limits of their user and method,
+// and emails repeated within duplicateSendWindow. Failed calls do not count
+// towards the daily caps, nor as sent emails.
+func (l *rateLimiter) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	user, now := callerID(ctx, req), time.Now()
+	digest, reserved, err := l.reserveEmail(user, req, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.allow(user, info.FullMethod, now); err != nil {
+		if reserved {
+			l.releaseEmail(digest)
+		}
+		return nil, err
+	}
+	resp, err := handler(ctx, req)
+	if err != nil {
+		l.refund(user, info.FullMethod, now)
+		if reserved {
+			l.releaseEmail(digest)
+		}
+	}
+	return resp, err
+}
+
+// streamInterceptor applies the limits to each message received on a
+// stream, as each carries its own CommonRequest.
+func (l *rateLimiter) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &rateLimitedStream{ServerStream: ss, limiter: l, method: info.FullMethod})
+}
+
+type rateLimitedStream struct {
+	grpc.ServerStream
+	limiter *rateLimiter
+	method  string
+}
+
+func (s *rateLimitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.limiter.allow(callerID(s.Context(), m), s.method, time.Now())
+}
@@ -0,0 +1,130 @@
+// mcp_services/rate_limit_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+func TestCallerIDIgnoresPeerPort(t *testing.T) {
+	withPeer := func(addr string) context.Context {
+		tcp, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: tcp})
+	}
+	req := &pb.SendEmailRequest{Common: &pb.CommonRequest{}}
+
+	first, second := callerID(withPeer("203.0.113.7:50123"), req), callerID(withPeer("203.0.113.7:50999"), req)
+	if first != "peer:203.0.113.7" || first != second {
+		t.Errorf("callerID = %q and %q, want peer:203.0.113.7 for both ports", first, second)
+	}
+	if got := callerID(withPeer("[2001:db8::1]:443"), req); got != "peer:2001:db8::1" {
+		t.Errorf("callerID = %q, want peer:2001:db8::1", got)
+	}
+
+	req.Common.UserId = "5491122334455"
+	if got := callerID(withPeer("203.0.113.7:50123"), req); got != "5491122334455" {
+		t.Errorf("callerID = %q, want the user ID", got)
+	}
+}
+
+func TestDailyCapCountsOnlySuccessfulCalls(t *testing.T) {
+	const method = "/mcp.GmailService/SendEmail"
+	l := newRateLimiter()
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	req := &pb.SendEmailRequest{Common: &pb.CommonRequest{UserId: "u1"}}
+
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("send failed")
+	}
+	for i := 0; i < 2; i++ {
+		l.unaryInterceptor(context.Background(), req, info, failing)
+	}
+	if c := l.daily[rateLimitKey{user: "u1", method: method}]; c != nil && c.count != 0 {
+		t.Errorf("daily count after failed calls = %d, want 0", c.count)
+	}
+
+	succeeding := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &pb.SendEmailResponse{}, nil
+	}
+	if _, err := l.unaryInterceptor(context.Background(), req, info, succeeding); err != nil {
+		t.Fatalf("unaryInterceptor: %v", err)
+	}
+	if c := l.daily[rateLimitKey{user: "u1", method: method}]; c == nil || c.count != 1 {
+		t.Errorf("daily count after a successful call = %v, want 1", c)
+	}
+}
+
+func TestDuplicateEmailsRejected(t *testing.T) {
+	l := newRateLimiter()
+	info := &grpc.UnaryServerInfo{FullMethod: "/mcp.GmailService/SendEmail"}
+	sends := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		sends++
+		return &pb.SendEmailResponse{}, nil
+	}
+	send := func(req *pb.SendEmailRequest) error {
+		_, err := l.unaryInterceptor(context.Background(), req, info, handler)
+		return err
+	}
+	email := func(userID, to, subject, body string) *pb.SendEmailRequest {
+		return &pb.SendEmailRequest{Common: &pb.CommonRequest{UserId: userID}, To: to, Subject: subject, Body: body}
+	}
+
+	if err := send(email("u1", "ana@example.com, Luis <luis@example.com>", "Hola", "Nos vemos")); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	err := send(email("u1", "LUIS@example.com, ana@example.com", "Hola", "Nos vemos"))
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("repeated send = %v, want AlreadyExists", err)
+	}
+
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		req  *pb.SendEmailRequest
+	}{
+		{"other user", email("u2", "ana@example.com, luis@example.com", "Hola", "Nos vemos")},
+		{"other recipients", email("u1", "ana@example.com", "Hola", "Nos vemos")},
+		{"other subject", email("u1", "ana@example.com, luis@example.com", "Hola de nuevo", "Nos vemos")},
+		{"other body", email("u1", "ana@example.com, luis@example.com", "Hola", "Nos vemos mañana")},
+	} {
+		if _, _, err := l.reserveEmail(tc.req.Common.UserId, tc.req, now); err != nil {
+			t.Errorf("%s: reserveEmail = %v, want it allowed", tc.name, err)
+		}
+	}
+	if _, ok, err := l.reserveEmail("u1", &pb.ListMessagesRequest{}, now); ok || err != nil {
+		t.Errorf("reserveEmail of a call that sends nothing = %v, %v, want false, nil", ok, err)
+	}
+	if sends != 1 {
+		t.Errorf("handler called %d times, want 1", sends)
+	}
+
+	// A failed send can be retried right away.
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("send failed")
+	}
+	req := email("u3", "ana@example.com", "Hola", "Nos vemos")
+	l.unaryInterceptor(context.Background(), req, info, failing)
+	if err := send(req); err != nil {
+		t.Errorf("send after a failed one = %v, want it sent", err)
+	}
+
+	// Identical emails can be sent again once duplicateSendWindow passes.
+	l.sweep(time.Now().Add(duplicateSendWindow + rateLimitSweepInterval))
+	if len(l.sent) != 0 {
+		t.Errorf("%d sent emails remembered after the window, want 0", len(l.sent))
+	}
+}
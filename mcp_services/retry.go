@@ -0,0 +1,253 @@
+// mcp_services/retry.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Environment variable overriding how many times calls to each service are
+// attempted, e.g. "gmail=2,drive=5". 1 disables retries for that service.
+const retryAttemptsEnv = "GOOGLE_API_MAX_ATTEMPTS"
+
+// retryPolicy says how failed calls to a Google service are retried.
+type retryPolicy struct {
+	maxAttempts int           // Including the first one
+	baseDelay   time.Duration // Upper bound of the first backoff, doubled after each attempt
+	maxDelay    time.Duration // Longest wait; a longer Retry-After is returned to the caller
+}
+
+var defaultRetryPolicy = retryPolicy{maxAttempts: 4, baseDelay: 500 * time.Millisecond, maxDelay: 20 * time.Second}
+
+// retryPolicies holds the policies of services that do not use
+// defaultRetryPolicy, including those set with retryAttemptsEnv.
+var retryPolicies = map[string]retryPolicy{
+	// Gmail enforces per-user rate limits that take seconds to recover from.
+	serviceGmail: {maxAttempts: 3, baseDelay: time.Second, maxDelay: 20 * time.Second},
+}
+
+// Gmail paths that send email. They are never retried: a send that failed
+// with a 5xx or a dropped connection may have been delivered anyway, so the
+// error goes back to the caller with its RetryInfo instead.
+var sendPathSuffixes = []string{"/messages/send", "/drafts/send"}
+
+// Reasons of 403 errors that only mean the caller is going too fast. Daily
+// limits are not retried, as they do not recover within a call.
+var rateLimitReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+}
+
+// loadRetryPolicies reads retryAttemptsEnv.
+func loadRetryPolicies() error {
+	value := strings.TrimSpace(os.Getenv(retryAttemptsEnv))
+	if value == "" {
+		return nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		service, attempts, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if _, known := serviceScopes[service]; !ok || !known {
+			return fmt.Errorf("%s: invalid entry %q, expected service=attempts with a service among %s",
+				retryAttemptsEnv, entry, strings.Join(knownServices(), ", "))
+		}
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 1 {
+			return fmt.Errorf("%s: invalid number of attempts %q for %s", retryAttemptsEnv, attempts, service)
+		}
+		policy := retryPolicyFor(service)
+		policy.maxAttempts = n
+		retryPolicies[service] = policy
+	}
+	return nil
+}
+
+func retryPolicyFor(service string) retryPolicy {
+	if policy, ok := retryPolicies[service]; ok {
+		return policy
+	}
+	return defaultRetryPolicy
+}
+
+// backoff returns a random wait before the attempt following attempt, up to
+// baseDelay doubled once per previous attempt ("full jitter").
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	limit := p.baseDelay << (attempt - 1)
+	if limit <= 0 || limit > p.maxDelay {
+		limit = p.maxDelay
+	}
+	return time.Duration(rand.Int64N(int64(limit))) + 1
+}
+
+// serviceForURL returns the service a Google API URL belongs to, or "" if
+// it is not known.
+func serviceForURL(u *url.URL) string {
+	switch sub, _, _ := strings.Cut(u.Hostname(), "."); sub {
+	case "gmail":
+		return serviceGmail
+	case "people":
+		return serviceContacts
+	case "tasks":
+		return serviceTasks
+	case "docs":
+		return serviceDocs
+	case "sheets":
+		return serviceSheets
+	}
+	switch {
+	case strings.HasPrefix(u.Path, "/calendar/"):
+		return serviceCalendar
+	case strings.HasPrefix(u.Path, "/drive/"), strings.HasPrefix(u.Path, "/upload/drive/"):
+		return serviceDrive
+	}
+	return ""
+}
+
+// googleClient returns an HTTP client that calls Google APIs with tok and
+// retries failed calls as allowed by the policy of their service.
+func googleClient(ctx context.Context, tok *oauth2.Token) *http.Client {
+	client := googleOAuthConfig.Client(ctx, tok)
+	client.Transport = &retryTransport{base: client.Transport}
+	return client
+}
+
+// retryTransport retries Google API calls that failed with a rate limit, a
+// server error or a network error, waiting with exponential backoff and
+// jitter, or as long as Retry-After says. Calls that are not idempotent are
+// only retried on rate limits, which Google answers before doing anything.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := retryPolicyFor(serviceForURL(req.URL))
+	if isSendRequest(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		// Sends are never retried, and streamed bodies cannot be replayed.
+		policy.maxAttempts = 1
+	}
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		req.Method == http.MethodPut || req.Method == http.MethodDelete
+
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= policy.maxAttempts {
+			return resp, err
+		}
+		retryAfter, retry := shouldRetry(resp, err, idempotent)
+		if !retry {
+			return resp, err
+		}
+		wait := policy.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > policy.maxDelay {
+				return resp, err
+			}
+			wait = retryAfter
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		log.Printf("Retrying %s %s in %v (attempt %d of %d failed: %s)",
+			req.Method, req.URL.Path, wait.Round(time.Millisecond), attempt, policy.maxAttempts, reason)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if attemptReq.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// isSendRequest reports whether req sends an email.
+func isSendRequest(req *http.Request) bool {
+	if !strings.Contains(req.URL.Path, "/gmail/v1/users/") {
+		return false
+	}
+	for _, suffix := range sendPathSuffixes {
+		if strings.HasSuffix(req.URL.Path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRetry reports whether a call that got resp or err can be retried,
+// and how long the server asked to wait first, if it did.
+func shouldRetry(resp *http.Response, err error, idempotent bool) (time.Duration, bool) {
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &retrieveErr) {
+			return 0, false
+		}
+		// The request may have reached Google before the connection failed.
+		return 0, idempotent
+	}
+
+	retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return retryAfter, true
+	case http.StatusForbidden:
+		return retryAfter, isRateLimited(resp)
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return retryAfter, idempotent
+	}
+	return 0, false
+}
+
+// isRateLimited reports whether a 403 response is a rate limit error. It
+// leaves the body readable for the caller.
+func isRateLimited(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var reply struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &reply) != nil {
+		return false
+	}
+	for _, item := range reply.Error.Errors {
+		if rateLimitReasons[item.Reason] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// mcp_services/retry_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// failingServer answers the first failures calls with status and Retry-After,
+// and the rest with an empty JSON object. It returns the server and how many
+// calls it got.
+func failingServer(t *testing.T, failures int, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, `{"error": {"code": 0, "message": "try later"}}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+var retryingClient = &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+
+func testCalendarService(t *testing.T, srv *httptest.Server) *calendar.Service {
+	t.Helper()
+	s, err := calendar.NewService(context.Background(), option.WithHTTPClient(retryingClient), option.WithEndpoint(srv.URL+"/calendar/v3/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testGmailService(t *testing.T, srv *httptest.Server) *gmail.Service {
+	t.Helper()
+	s, err := gmail.NewService(context.Background(), option.WithHTTPClient(retryingClient), option.WithEndpoint(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv, calls := failingServer(t, 1, status, "1")
+
+		start := time.Now()
+		_, err := testCalendarService(t, srv).Events.List("primary").Context(context.Background()).Do()
+		if err != nil {
+			t.Errorf("%d then 200: %v", status, err)
+		}
+		if n := calls.Load(); n != 2 {
+			t.Errorf("%d then 200: %d calls, want 2", status, n)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("%d with Retry-After: 1 retried after %v", status, elapsed)
+		}
+	}
+}
+
+func TestRetryTransportGivesUpOnLongRetryAfter(t *testing.T) {
+	srv, calls := failingServer(t, 1, http.StatusTooManyRequests, "3600")
+
+	if _, err := testCalendarService(t, srv).Events.List("primary").Context(context.Background()).Do(); err == nil {
+		t.Error("call succeeded, want the 429 back")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d calls, want 1", n)
+	}
+}
+
+func TestRetryTransportRetriesOnlyRateLimitsOfWrites(t *testing.T) {
+	srv, calls := failingServer(t, 1, http.StatusServiceUnavailable, "1")
+	if _, err := testCalendarService(t, srv).Events.Insert("primary", &calendar.Event{}).Context(context.Background()).Do(); err == nil {
+		t.Error("insert succeeded, want the 503 back")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("insert after a 503: %d calls, want 1", n)
+	}
+
+	srv, calls = failingServer(t, 1, http.StatusTooManyRequests, "1")
+	if _, err := testCalendarService(t, srv).Events.Insert("primary", &calendar.Event{}).Context(context.Background()).Do(); err != nil {
+		t.Errorf("insert after a 429: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("insert after a 429: %d calls, want 2", n)
+	}
+}
+
+func TestRetryTransportNeverRetriesSends(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv, calls := failingServer(t, 1, status, "1")
+
+		_, err := testGmailService(t, srv).Users.Messages.Send("me", newRawMessage("a@example.com", "Hola", "body")).Context(context.Background()).Do()
+		if err == nil {
+			t.Errorf("send after a %d succeeded, want the error back", status)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("send after a %d: %d calls, want 1", status, n)
+		}
+	}
+}
+
+func TestRetryTransportStopsWhenContextIsDone(t *testing.T) {
+	srv, calls := failingServer(t, 1, http.StatusTooManyRequests, "10")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := testCalendarService(t, srv).Events.List("primary").Context(ctx).Do()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %v for a cancelled call", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d calls, want 1", n)
+	}
+}
@@ -0,0 +1,501 @@
+// mcp_services/scheduled_email.go
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/mail"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// File where scheduled emails are persisted between restarts
+	scheduledEmailsFile = "scheduled_emails.json"
+	// How often the scheduler looks for emails that are due
+	schedulerInterval = 30 * time.Second
+	// Maximum number of send attempts before an email is marked as failed
+	maxSendAttempts = 5
+	// Delay before the first retry; it doubles on every further attempt
+	sendRetryBaseDelay = time.Minute
+	// Longest a single send may take, so a hung call cannot stall the scheduler
+	scheduledSendTimeout = time.Minute
+)
+
+// Scheduled email states, as exposed in pb.ScheduledEmail.Status
+const (
+	scheduledStatusPending   = "PENDING"
+	scheduledStatusSending   = "SENDING"
+	scheduledStatusSent      = "SENT"
+	scheduledStatusFailed    = "FAILED"
+	scheduledStatusCancelled = "CANCELLED"
+)
+
+var (
+	errScheduledEmailNotFound   = errors.New("scheduled email not found")
+	errScheduledEmailNotPending = errors.New("scheduled email is no longer pending")
+	// errEmailNotSent marks send failures that happened before the email was
+	// handed to Gmail.
+	errEmailNotSent = errors.New("email not sent")
+)
+
+// scheduledEmail is the persisted form of an email queued for later delivery.
+// The scheduler sends it on the user's behalf without an incoming request,
+// with the tokens the server stores for UserID.
+type scheduledEmail struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	To          string    `json:"to"`
+	Subject     string    `json:"subject"`
+	Body        string    `json:"body"`
+	SendAt      time.Time `json:"send_at"`
+	NextAttempt time.Time `json:"next_attempt"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	MessageID   string    `json:"message_id,omitempty"`
+}
+
+func (e *scheduledEmail) toProto() *pb.ScheduledEmail {
+	return &pb.ScheduledEmail{
+		Id:        e.ID,
+		To:        e.To,
+		Subject:   e.Subject,
+		Body:      e.Body,
+		SendAt:    e.SendAt.Format(time.RFC3339),
+		Status:    e.Status,
+		Attempts:  int32(e.Attempts),
+		LastError: e.LastError,
+		MessageId: e.MessageID,
+	}
+}
+
+// scheduledEmailStore is a durable, file-backed queue of scheduled emails.
+// Every mutation rewrites the whole file, which is fine for the handful of
+// emails a personal assistant keeps queued.
+type scheduledEmailStore struct {
+	mu     sync.Mutex
+	path   string
+	emails map[string]*scheduledEmail
+}
+
+// newScheduledEmailStore loads the store from path, creating it if missing.
+// Emails that were being sent when the process stopped are marked as failed,
+// since we cannot know whether Gmail accepted them and must not send twice.
+func newScheduledEmailStore(path string) (*scheduledEmailStore, error) {
+	s := &scheduledEmailStore{path: path, emails: make(map[string]*scheduledEmail)}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	var emails []*scheduledEmail
+	if err := json.Unmarshal(b, &emails); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	interrupted := false
+	for _, e := range emails {
+		if e.Status == scheduledStatusSending {
+			e.Status = scheduledStatusFailed
+			e.LastError = "interrupted while sending; delivery state unknown"
+			interrupted = true
+		}
+		s.emails[e.ID] = e
+	}
+	if interrupted {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// save writes the store to disk. Callers must hold s.mu.
+func (s *scheduledEmailStore) save() error {
+	emails := make([]*scheduledEmail, 0, len(s.emails))
+	for _, e := range s.emails {
+		emails = append(emails, e)
+	}
+	sort.Slice(emails, func(i, j int) bool { return emails[i].SendAt.Before(emails[j].SendAt) })
+
+	b, err := json.MarshalIndent(emails, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal scheduled emails: %w", err)
+	}
+	// Write to a temporary file and rename it so a crash never leaves a truncated store.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("unable to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("unable to replace %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Add queues a new email.
+func (s *scheduledEmailStore) Add(e *scheduledEmail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails[e.ID] = e
+	if err := s.save(); err != nil {
+		delete(s.emails, e.ID)
+		return err
+	}
+	return nil
+}
+
+// List returns copies of the emails scheduled by userID, ordered by send time.
+// Sent, failed and cancelled emails are only included if includeFinished is set.
+func (s *scheduledEmailStore) List(userID string, includeFinished bool) []*scheduledEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*scheduledEmail
+	for _, e := range s.emails {
+		if e.UserID != userID {
+			continue
+		}
+		if !includeFinished && e.Status != scheduledStatusPending && e.Status != scheduledStatusSending {
+			continue
+		}
+		c := *e
+		out = append(out, &c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SendAt.Before(out[j].SendAt) })
+	return out
+}
+
+// Cancel marks a pending email of userID as cancelled.
+func (s *scheduledEmailStore) Cancel(userID, id string) (*scheduledEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.emails[id]
+	if !ok || e.UserID != userID {
+		return nil, errScheduledEmailNotFound
+	}
+	if e.Status != scheduledStatusPending {
+		return nil, errScheduledEmailNotPending
+	}
+	e.Status = scheduledStatusCancelled
+	if err := s.save(); err != nil {
+		e.Status = scheduledStatusPending
+		return nil, err
+	}
+	c := *e
+	return &c, nil
+}
+
+// CancelForUser marks every pending email of userID as cancelled and
+// returns how many there were.
+func (s *scheduledEmailStore) CancelForUser(userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cancelled []*scheduledEmail
+	for _, e := range s.emails {
+		if e.UserID == userID && e.Status == scheduledStatusPending {
+			e.Status = scheduledStatusCancelled
+			cancelled = append(cancelled, e)
+		}
+	}
+	if len(cancelled) == 0 {
+		return 0, nil
+	}
+	if err := s.save(); err != nil {
+		for _, e := range cancelled {
+			e.Status = scheduledStatusPending
+		}
+		return 0, err
+	}
+	return len(cancelled), nil
+}
+
+// ClaimDue marks every pending email whose next attempt is due as being sent
+// and returns copies of them. Claimed emails can no longer be cancelled.
+func (s *scheduledEmailStore) ClaimDue(now time.Time) ([]*scheduledEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*scheduledEmail
+	for _, e := range s.emails {
+		if e.Status == scheduledStatusPending && !e.NextAttempt.After(now) {
+			e.Status = scheduledStatusSending
+			c := *e
+			due = append(due, &c)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	if err := s.save(); err != nil {
+		for _, c := range due {
+			s.emails[c.ID].Status = scheduledStatusPending
+		}
+		return nil, err
+	}
+	return due, nil
+}
+
+// Update replaces the stored copy of an email.
+func (s *scheduledEmailStore) Update(e *scheduledEmail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.emails[e.ID]; !ok {
+		return errScheduledEmailNotFound
+	}
+	c := *e
+	s.emails[e.ID] = &c
+	return s.save()
+}
+
+// emailScheduler periodically sends the emails in a scheduledEmailStore that are due.
+type emailScheduler struct {
+	store    *scheduledEmailStore
+	interval time.Duration
+	// send delivers an email and returns its Gmail message ID.
+	send func(ctx context.Context, e *scheduledEmail) (string, error)
+}
+
+// Run processes due emails every s.interval until ctx is cancelled.
+func (s *emailScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.processDue(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.processDue(ctx, now)
+		}
+	}
+}
+
+func (s *emailScheduler) processDue(ctx context.Context, now time.Time) {
+	due, err := s.store.ClaimDue(now)
+	if err != nil {
+		log.Printf("Unable to claim due scheduled emails: %v", err)
+		return
+	}
+
+	for _, e := range due {
+		e.Attempts++
+		sendCtx, cancel := context.WithTimeout(ctx, scheduledSendTimeout)
+		messageID, err := s.send(sendCtx, e)
+		cancel()
+		if err != nil {
+			e.LastError = err.Error()
+			if sendMayHaveSucceeded(err) {
+				// Sending again could deliver the email twice.
+				e.Status = scheduledStatusFailed
+				e.LastError = "delivery state unknown: " + e.LastError
+				log.Printf("Scheduled email %s may or may not have been sent; not retrying: %v", e.ID, err)
+			} else if e.Attempts >= maxSendAttempts {
+				e.Status = scheduledStatusFailed
+				log.Printf("Giving up on scheduled email %s after %d attempts: %v", e.ID, e.Attempts, err)
+			} else {
+				e.Status = scheduledStatusPending
+				e.NextAttempt = now.Add(sendRetryBaseDelay << (e.Attempts - 1))
+				log.Printf("Scheduled email %s failed (attempt %d), retrying at %s: %v", e.ID, e.Attempts, e.NextAttempt.Format(time.RFC3339), err)
+			}
+		} else {
+			e.Status = scheduledStatusSent
+			e.MessageID = messageID
+			e.LastError = ""
+			log.Printf("Scheduled email %s sent (message ID %s).", e.ID, messageID)
+		}
+
+		if err := s.store.Update(e); err != nil {
+			log.Printf("Unable to update scheduled email %s: %v", e.ID, err)
+		}
+	}
+}
+
+// sendMayHaveSucceeded reports whether Gmail may have accepted an email
+// whose send failed with err. Only failures that happened before the
+// request was written, and client errors other than rate limits, certainly
+// left it unsent.
+func sendMayHaveSucceeded(err error) bool {
+	if errors.Is(err, errEmailNotSent) {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code < 400 || apiErr.Code >= 500 || apiErr.Code == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	return true
+}
+
+// sendScheduledEmail sends e through Gmail with the tokens stored for its user.
+func sendScheduledEmail(ctx context.Context, e *scheduledEmail) (string, error) {
+	stored, err := userTokens.Get(e.UserID)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to get OAuth token of user %s: %w", errEmailNotSent, e.UserID, err)
+	}
+	tok, err := googleOAuthConfig.TokenSource(ctx, stored).Token()
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to get fresh token: %w", errEmailNotSent, err)
+	}
+	if tok.AccessToken != stored.AccessToken {
+		if err := userTokens.Put(e.UserID, tok); err != nil {
+			log.Printf("Unable to store OAuth token for user %s: %v", e.UserID, err)
+		}
+	}
+
+	srv, err := googleService(ctx, e.UserID, tok, gmail.NewService)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to retrieve Gmail client: %w", errEmailNotSent, err)
+	}
+
+	to, err := formatAddressList(e.To)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid recipients %q: %w", errEmailNotSent, e.To, err)
+	}
+	sent, err := srv.Users.Messages.Send("me", newRawMessage(to, e.Subject, e.Body)).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("unable to send email: %w", err)
+	}
+	return sent.Id, nil
+}
+
+// formatAddressList parses a list of email addresses, such as the recipients
+// of an email, and formats it back so it can be written to a header as is.
+func formatAddressList(list string) (string, error) {
+	addrs, err := mail.ParseAddressList(list)
+	if err != nil {
+		return "", err
+	}
+	formatted := make([]string, len(addrs))
+	for i, a := range addrs {
+		if a.Name == "" {
+			formatted[i] = a.Address
+		} else {
+			formatted[i] = a.String()
+		}
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// newScheduledEmailID returns a random identifier for a scheduled email.
+func newScheduledEmailID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *gmailServer) ScheduleEmail(ctx context.Context, req *pb.ScheduleEmailRequest) (*pb.ScheduleEmailResponse, error) {
+	sendAt, err := time.Parse(time.RFC3339, req.SendAt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "send_at must be in RFC3339 format: %v", err)
+	}
+	if sendAt.Before(time.Now()) {
+		return nil, status.Errorf(codes.InvalidArgument, "send_at %s is in the past.", req.SendAt)
+	}
+	if req.To == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A recipient is required.")
+	}
+	// The email is sent later without the user watching, so only well-formed
+	// addresses are queued; they cannot carry extra header lines.
+	to, err := formatAddressList(req.To)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid recipients %q: %v", req.To, err)
+	}
+
+	// The scheduler sends the email with the tokens stored for the user, so
+	// make sure there are some now rather than fail when it is due.
+	if _, err := getTokenFromRequest(ctx, req.Common); err != nil {
+		return nil, err
+	}
+
+	id, err := newScheduledEmailID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to generate scheduled email ID: %v", err)
+	}
+	e := &scheduledEmail{
+		ID:          id,
+		UserID:      req.Common.UserId,
+		To:          to,
+		Subject:     req.Subject,
+		Body:        req.Body,
+		SendAt:      sendAt,
+		NextAttempt: sendAt,
+		Status:      scheduledStatusPending,
+	}
+	if err := s.scheduled.Add(e); err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to store scheduled email: %v", err)
+	}
+
+	return &pb.ScheduleEmailResponse{
+		Common:         &pb.CommonResponse{Status: "OK", Message: "Email scheduled successfully."},
+		ScheduledEmail: e.toProto(),
+	}, nil
+}
+
+func (s *gmailServer) ListScheduled(ctx context.Context, req *pb.ListScheduledRequest) (*pb.ListScheduledResponse, error) {
+	userID := req.Common.GetUserId()
+	if userID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "No user ID provided in request.")
+	}
+
+	var pbEmails []*pb.ScheduledEmail
+	for _, e := range s.scheduled.List(userID, req.IncludeFinished) {
+		pbEmails = append(pbEmails, e.toProto())
+	}
+
+	return &pb.ListScheduledResponse{
+		Common:          &pb.CommonResponse{Status: "OK", Message: "Scheduled emails listed successfully."},
+		ScheduledEmails: pbEmails,
+	}, nil
+}
+
+func (s *gmailServer) CancelScheduled(ctx context.Context, req *pb.CancelScheduledRequest) (*pb.CancelScheduledResponse, error) {
+	userID := req.Common.GetUserId()
+	if userID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "No user ID provided in request.")
+	}
+
+	e, err := s.scheduled.Cancel(userID, req.Id)
+	switch {
+	case errors.Is(err, errScheduledEmailNotFound):
+		return nil, status.Errorf(codes.NotFound, "Scheduled email %s not found.", req.Id)
+	case errors.Is(err, errScheduledEmailNotPending):
+		return nil, status.Errorf(codes.FailedPrecondition, "Scheduled email %s can no longer be cancelled.", req.Id)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Unable to cancel scheduled email: %v", err)
+	}
+
+	return &pb.CancelScheduledResponse{
+		Common:         &pb.CommonResponse{Status: "OK", Message: "Scheduled email cancelled successfully."},
+		ScheduledEmail: e.toProto(),
+	}, nil
+}
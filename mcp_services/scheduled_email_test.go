@@ -0,0 +1,113 @@
+// mcp_services/scheduled_email_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestFormatAddressList(t *testing.T) {
+	tests := []struct {
+		list string
+		want string
+	}{
+		{"ana@example.com", "ana@example.com"},
+		{"Ana <ana@example.com>, bruno@example.com", `"Ana" <ana@example.com>, bruno@example.com`},
+		{"José <jose@example.com>", "=?utf-8?q?Jos=C3=A9?= <jose@example.com>"},
+	}
+	for _, tt := range tests {
+		if got, err := formatAddressList(tt.list); err != nil || got != tt.want {
+			t.Errorf("formatAddressList(%q) = %q, %v, want %q", tt.list, got, err, tt.want)
+		}
+	}
+
+	for _, list := range []string{
+		"ana@example.com\r\nBcc: victim@example.com",
+		"ana@example.com\nBcc: victim@example.com",
+		"\"Ana\r\nBcc: victim@example.com\" <ana@example.com>",
+		"not an address",
+		"",
+	} {
+		if got, err := formatAddressList(list); err == nil {
+			t.Errorf("formatAddressList(%q) = %q, want an error", list, got)
+		}
+	}
+}
+
+func TestProcessDue(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name            string
+		attempts        int
+		sendErr         error
+		wantStatus      string
+		wantAttempts    int
+		wantNextAttempt time.Time
+	}{
+		{"sent", 0, nil, scheduledStatusSent, 1, now},
+		{"bad request is retried", 0, &googleapi.Error{Code: 400}, scheduledStatusPending, 1, now.Add(sendRetryBaseDelay)},
+		{"backoff doubles", 2, &googleapi.Error{Code: 403}, scheduledStatusPending, 3, now.Add(4 * sendRetryBaseDelay)},
+		{"failure before sending is retried", 0, fmt.Errorf("%w: no token", errEmailNotSent), scheduledStatusPending, 1, now.Add(sendRetryBaseDelay)},
+		{"refused connection is retried", 0, fmt.Errorf("Post: %w", dialErr), scheduledStatusPending, 1, now.Add(sendRetryBaseDelay)},
+		{"gives up after the last attempt", maxSendAttempts - 1, &googleapi.Error{Code: 400}, scheduledStatusFailed, maxSendAttempts, now},
+		{"server error is not retried", 0, &googleapi.Error{Code: 503}, scheduledStatusFailed, 1, now},
+		{"rate limit is not retried", 0, &googleapi.Error{Code: 429}, scheduledStatusFailed, 1, now},
+		{"timeout is not retried", 0, context.DeadlineExceeded, scheduledStatusFailed, 1, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := newScheduledEmailStore(filepath.Join(t.TempDir(), "scheduled.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			due := &scheduledEmail{ID: "due", UserID: "5491122334455", SendAt: now, NextAttempt: now, Status: scheduledStatusPending, Attempts: tt.attempts}
+			later := &scheduledEmail{ID: "later", UserID: "5491122334455", SendAt: now.Add(time.Hour), NextAttempt: now.Add(time.Hour), Status: scheduledStatusPending}
+			for _, e := range []*scheduledEmail{due, later} {
+				if err := store.Add(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var sent []string
+			s := &emailScheduler{store: store, send: func(ctx context.Context, e *scheduledEmail) (string, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("send called without a deadline")
+				}
+				sent = append(sent, e.ID)
+				return "msg-1", tt.sendErr
+			}}
+			s.processDue(context.Background(), now)
+
+			if len(sent) != 1 || sent[0] != "due" {
+				t.Fatalf("sent %v, want [due]", sent)
+			}
+			got := map[string]*scheduledEmail{}
+			for _, e := range store.List("5491122334455", true) {
+				got[e.ID] = e
+			}
+			e := got["due"]
+			if e.Status != tt.wantStatus || e.Attempts != tt.wantAttempts || !e.NextAttempt.Equal(tt.wantNextAttempt) {
+				t.Errorf("got status %s, attempts %d, next attempt %s; want %s, %d, %s",
+					e.Status, e.Attempts, e.NextAttempt, tt.wantStatus, tt.wantAttempts, tt.wantNextAttempt)
+			}
+			if tt.sendErr == nil && e.MessageID != "msg-1" {
+				t.Errorf("MessageID = %q, want msg-1", e.MessageID)
+			}
+			if tt.sendErr != nil && e.LastError == "" {
+				t.Error("LastError is empty")
+			}
+			if l := got["later"]; l.Status != scheduledStatusPending || l.Attempts != 0 {
+				t.Errorf("email not yet due changed: status %s, attempts %d", l.Status, l.Attempts)
+			}
+		})
+	}
+}
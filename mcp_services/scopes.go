@@ -0,0 +1,211 @@
+// mcp_services/scopes.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/people/v1"
+	"google.golang.org/api/sheets/v4"
+	"google.golang.org/api/tasks/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Google services users grant access to one at a time, the first time they
+// use them. Also the values of StartAuthorizationRequest.services.
+const (
+	serviceCalendar = "calendar"
+	serviceGmail    = "gmail"
+	serviceContacts = "contacts"
+	serviceTasks    = "tasks"
+	serviceDrive    = "drive"
+	serviceDocs     = "docs"
+	serviceSheets   = "sheets"
+)
+
+// Scopes requested when a user first connects their account, which only
+// identify it. Service scopes are added as they are needed.
+var baseScopes = []string{
+	"openid",
+	people.UserinfoEmailScope,
+}
+
+// serviceScopes lists the OAuth scopes each service needs.
+var serviceScopes = map[string][]string{
+	serviceCalendar: {
+		calendar.CalendarEventsScope, // Full access to Calendar events
+	},
+	serviceGmail: {
+		gmail.GmailModifyScope,        // Full access to Gmail messages, including sending
+		gmail.GmailSettingsBasicScope, // Vacation responder and filters
+	},
+	serviceContacts: {
+		people.ContactsScope,              // Full access to Contacts
+		people.ContactsOtherReadonlyScope, // Search people the user has interacted with
+	},
+	serviceTasks:  {tasks.TasksScope},         // Full access to Tasks
+	serviceDrive:  {drive.DriveScope},         // Search, download, upload and share Drive files
+	serviceDocs:   {docs.DocumentsScope},      // Create, read and write Google Docs
+	serviceSheets: {sheets.SpreadsheetsScope}, // Read and write Google Sheets
+}
+
+// grpcServices maps each gRPC service to the Google service its methods use.
+var grpcServices = map[string]string{
+	"mcp.CalendarService": serviceCalendar,
+	"mcp.GmailService":    serviceGmail,
+	"mcp.ContactsService": serviceContacts,
+	"mcp.TasksService":    serviceTasks,
+	"mcp.DriveService":    serviceDrive,
+	"mcp.DocsService":     serviceDocs,
+	"mcp.SheetsService":   serviceSheets,
+}
+
+// methodServices lists the Google services of methods that use more than
+// the one of their gRPC service.
+var methodServices = map[string][]string{
+	"/mcp.CalendarService/GetMeetingBriefing": {serviceCalendar, serviceContacts, serviceGmail, serviceDrive},
+}
+
+// servicesForMethod returns the Google services a gRPC method needs access to.
+func servicesForMethod(fullMethod string) []string {
+	if services, ok := methodServices[fullMethod]; ok {
+		return services
+	}
+	grpcService := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(grpcService, "/"); i >= 0 {
+		grpcService = grpcService[:i]
+	}
+	if service, ok := grpcServices[grpcService]; ok {
+		return []string{service}
+	}
+	return nil
+}
+
+// knownServices returns the names of the services in serviceScopes, sorted.
+func knownServices() []string {
+	known := make([]string, 0, len(serviceScopes))
+	for k := range serviceScopes {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+	return known
+}
+
+// scopesForServices returns the scopes of services, or an error naming an
+// unknown service.
+func scopesForServices(services []string) ([]string, error) {
+	var scopes []string
+	for _, service := range services {
+		s, ok := serviceScopes[service]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q, expected one of %s", service, strings.Join(knownServices(), ", "))
+		}
+		scopes = append(scopes, s...)
+	}
+	return scopes, nil
+}
+
+// missingServices returns the services whose scopes are not all granted.
+// nil granted scopes come from tokens stored before scopes were tracked,
+// which were granted every service.
+func missingServices(granted []string, services []string) []string {
+	if granted == nil {
+		return nil
+	}
+	have := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		have[s] = true
+	}
+	var missing []string
+	for _, service := range services {
+		for _, scope := range serviceScopes[service] {
+			if !have[scope] {
+				missing = append(missing, service)
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// authorizationError returns a gRPC error with code c whose message and
+// Help details carry a link granting the scopes of services to userID.
+func authorizationError(c codes.Code, userID, message string, services []string) error {
+	scopes, _ := scopesForServices(services)
+	authURL, _, err := authorizations.Start(userID, scopes)
+	if err != nil {
+		return status.Errorf(c, "%s", message)
+	}
+	st := status.Newf(c, "%s Authorize at %s", message, authURL)
+	if withHelp, err := st.WithDetails(&errdetails.Help{
+		Links: []*errdetails.Help_Link{{Description: "Authorize access to the user's Google account", Url: authURL}},
+	}); err == nil {
+		st = withHelp
+	}
+	return st.Err()
+}
+
+// scopeInterceptor rejects calls for users who have not granted the scopes
+// of the Google services the method uses, with a link to grant them:
+// Unauthenticated if the user has not connected an account at all, and
+// PermissionDenied if they have but without those scopes. It adds the same
+// link to errors of calls Google rejected for lack of scopes or because the
+// user revoked access.
+func scopeInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	services := servicesForMethod(info.FullMethod)
+	withCommon, ok := req.(interface{ GetCommon() *pb.CommonRequest })
+	if len(services) == 0 || !ok || withCommon.GetCommon().GetUserId() == "" {
+		return handler(ctx, req)
+	}
+	userID := withCommon.GetCommon().GetUserId()
+
+	grant, err := userTokens.Grant(userID)
+	switch {
+	case errors.Is(err, errTokenNotFound):
+		return nil, authorizationError(codes.Unauthenticated, userID,
+			fmt.Sprintf("No Google account is authorized for user %s.", userID), services)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Unable to get granted scopes: %v", err)
+	}
+
+	if missing := missingServices(grant.Scopes, services); len(missing) > 0 {
+		return nil, authorizationError(codes.PermissionDenied, userID,
+			fmt.Sprintf("Access to %s has not been granted yet.", strings.Join(missing, ", ")), missing)
+	}
+
+	resp, err := handler(ctx, req)
+	if needsAuthorization(err) {
+		// Google disagrees with the stored scopes, or the user revoked access.
+		st := status.Convert(err)
+		return nil, authorizationError(st.Code(), userID, st.Message(), services)
+	}
+	return resp, err
+}
+
+// needsAuthorization reports whether err, translated by googleAPIError,
+// can only be fixed by the user authorizing access again.
+func needsAuthorization(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok &&
+			(info.Reason == scopeInsufficientReason || info.Reason == authorizationRevokedReason) {
+			return true
+		}
+	}
+	return false
+}
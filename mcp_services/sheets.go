@@ -0,0 +1,166 @@
+// mcp_services/sheets.go
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/sheets/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Sheets value input options, see AppendRowsRequest.raw
+const (
+	sheetsInputRaw         = "RAW"
+	sheetsInputUserEntered = "USER_ENTERED"
+)
+
+// ====================================================================
+// Sheets Service Implementation
+// ====================================================================
+type sheetsServer struct {
+	pb.UnimplementedSheetsServiceServer
+}
+
+func sheetsValueInputOption(raw bool) string {
+	if raw {
+		return sheetsInputRaw
+	}
+	return sheetsInputUserEntered
+}
+
+func sheetRowsToValues(rows []*pb.SheetRow) [][]interface{} {
+	values := make([][]interface{}, 0, len(rows))
+	for _, r := range rows {
+		row := make([]interface{}, 0, len(r.Values))
+		for _, v := range r.Values {
+			row = append(row, v)
+		}
+		values = append(values, row)
+	}
+	return values
+}
+
+// validateSheetWrite checks the fields shared by AppendRows and UpdateRange.
+func validateSheetWrite(spreadsheetID, rangeA1 string, rows []*pb.SheetRow) error {
+	if spreadsheetID == "" {
+		return status.Errorf(codes.InvalidArgument, "A spreadsheet ID is required.")
+	}
+	if rangeA1 == "" {
+		return status.Errorf(codes.InvalidArgument, "A range is required.")
+	}
+	if len(rows) == 0 {
+		return status.Errorf(codes.InvalidArgument, "At least one row is required.")
+	}
+	return nil
+}
+
+func (s *sheetsServer) ReadRange(ctx context.Context, req *pb.ReadRangeRequest) (*pb.ReadRangeResponse, error) {
+	if req.SpreadsheetId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A spreadsheet ID is required.")
+	}
+	if req.Range == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A range is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, sheets.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Sheets client: %v", err)
+	}
+
+	resp, err := srv.Spreadsheets.Values.Get(req.SpreadsheetId, req.Range).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to read range")
+	}
+
+	var rows []*pb.SheetRow
+	for _, r := range resp.Values {
+		row := &pb.SheetRow{}
+		for _, v := range r {
+			row.Values = append(row.Values, fmt.Sprint(v))
+		}
+		rows = append(rows, row)
+	}
+
+	return &pb.ReadRangeResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Range read successfully."},
+		Range:  resp.Range,
+		Rows:   rows,
+	}, nil
+}
+
+func (s *sheetsServer) AppendRows(ctx context.Context, req *pb.AppendRowsRequest) (*pb.AppendRowsResponse, error) {
+	if err := validateSheetWrite(req.SpreadsheetId, req.Range, req.Rows); err != nil {
+		return nil, err
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, sheets.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Sheets client: %v", err)
+	}
+
+	resp, err := srv.Spreadsheets.Values.Append(req.SpreadsheetId, req.Range, &sheets.ValueRange{Values: sheetRowsToValues(req.Rows)}).
+		ValueInputOption(sheetsValueInputOption(req.Raw)).
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to append rows")
+	}
+
+	updatedRange, updatedRows := "", int32(0)
+	if resp.Updates != nil {
+		updatedRange = resp.Updates.UpdatedRange
+		updatedRows = int32(resp.Updates.UpdatedRows)
+	}
+
+	return &pb.AppendRowsResponse{
+		Common:       &pb.CommonResponse{Status: "OK", Message: "Rows appended successfully."},
+		UpdatedRange: updatedRange,
+		UpdatedRows:  updatedRows,
+	}, nil
+}
+
+func (s *sheetsServer) UpdateRange(ctx context.Context, req *pb.UpdateRangeRequest) (*pb.UpdateRangeResponse, error) {
+	if err := validateSheetWrite(req.SpreadsheetId, req.Range, req.Rows); err != nil {
+		return nil, err
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, sheets.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Sheets client: %v", err)
+	}
+
+	resp, err := srv.Spreadsheets.Values.Update(req.SpreadsheetId, req.Range, &sheets.ValueRange{Values: sheetRowsToValues(req.Rows)}).
+		ValueInputOption(sheetsValueInputOption(req.Raw)).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to update range")
+	}
+
+	return &pb.UpdateRangeResponse{
+		Common:       &pb.CommonResponse{Status: "OK", Message: "Range updated successfully."},
+		UpdatedRange: resp.UpdatedRange,
+		UpdatedRows:  int32(resp.UpdatedRows),
+		UpdatedCells: int32(resp.UpdatedCells),
+	}, nil
+}
@@ -0,0 +1,317 @@
+// mcp_services/tasks.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/tasks/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+const (
+	// Task list used when a request does not name one
+	defaultTaskListID = "@default"
+	// Default and maximum number of tasks returned by ListTasks
+	defaultTaskResults = 20
+	maxTaskResults     = 100
+
+	taskStatusCompleted = "completed"
+)
+
+// ====================================================================
+// Tasks Service Implementation
+// ====================================================================
+type tasksServer struct {
+	pb.UnimplementedTasksServiceServer
+}
+
+func taskListID(id string) string {
+	if id == "" {
+		return defaultTaskListID
+	}
+	return id
+}
+
+// parseTaskDue accepts "YYYY-MM-DD" or RFC3339 and returns the midnight UTC
+// timestamp Google Tasks expects, since it drops the time of day anyway.
+func parseTaskDue(due string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", due); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date %q, expected YYYY-MM-DD", due)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func taskToProto(listID string, t *tasks.Task) *pb.Task {
+	due := t.Due
+	if d, err := time.Parse(time.RFC3339, t.Due); err == nil {
+		due = d.Format("2006-01-02")
+	}
+	completed := ""
+	if t.Completed != nil {
+		completed = *t.Completed
+	}
+	return &pb.Task{
+		Id:         t.Id,
+		TaskListId: listID,
+		Title:      t.Title,
+		Notes:      t.Notes,
+		Due:        due,
+		Status:     t.Status,
+		Completed:  completed,
+		Parent:     t.Parent,
+		WebLink:    t.WebViewLink,
+	}
+}
+
+func (s *tasksServer) ListTaskLists(ctx context.Context, req *pb.ListTaskListsRequest) (*pb.ListTaskListsResponse, error) {
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	var pbLists []*pb.TaskList
+	err = srv.Tasklists.List().MaxResults(maxTaskResults).Pages(ctx, func(page *tasks.TaskLists) error {
+		for _, l := range page.Items {
+			pbLists = append(pbLists, &pb.TaskList{Id: l.Id, Title: l.Title, Updated: l.Updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list task lists")
+	}
+
+	return &pb.ListTaskListsResponse{
+		Common:    &pb.CommonResponse{Status: "OK", Message: "Task lists listed successfully."},
+		TaskLists: pbLists,
+	}, nil
+}
+
+func (s *tasksServer) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	limit := int64(req.MaxResults)
+	if limit <= 0 {
+		limit = defaultTaskResults
+	}
+	if limit > maxTaskResults {
+		limit = maxTaskResults
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	listID := taskListID(req.TaskListId)
+	call := srv.Tasks.List(listID).MaxResults(limit).ShowCompleted(req.ShowCompleted)
+	if req.ShowCompleted {
+		// Tasks completed in the Google apps are hidden, not just completed.
+		call = call.ShowHidden(true)
+	}
+	if req.DueAfter != "" {
+		after, err := parseTaskDue(req.DueAfter)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		call = call.DueMin(after.Format(time.RFC3339))
+	}
+	if req.DueBefore != "" {
+		before, err := parseTaskDue(req.DueBefore)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		// due_before is inclusive, dueMax is not.
+		call = call.DueMax(before.AddDate(0, 0, 1).Format(time.RFC3339))
+	}
+
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to list tasks")
+	}
+
+	var pbTasks []*pb.Task
+	for _, t := range resp.Items {
+		pbTasks = append(pbTasks, taskToProto(listID, t))
+	}
+
+	return &pb.ListTasksResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Tasks listed successfully."},
+		Tasks:  pbTasks,
+	}, nil
+}
+
+func (s *tasksServer) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.CreateTaskResponse, error) {
+	if req.Title == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A task title is required.")
+	}
+	task := &tasks.Task{
+		Title: req.Title,
+		Notes: req.Notes,
+	}
+	if req.Due != "" {
+		due, err := parseTaskDue(req.Due)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		task.Due = due.Format(time.RFC3339)
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	listID := taskListID(req.TaskListId)
+	created, err := srv.Tasks.Insert(listID, task).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to create task")
+	}
+
+	return &pb.CreateTaskResponse{
+		Common:      &pb.CommonResponse{Status: "OK", Message: "Task created successfully."},
+		CreatedTask: taskToProto(listID, created),
+	}, nil
+}
+
+func (s *tasksServer) CompleteTask(ctx context.Context, req *pb.CompleteTaskRequest) (*pb.CompleteTaskResponse, error) {
+	if req.TaskId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A task ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	listID := taskListID(req.TaskListId)
+	completed, err := srv.Tasks.Patch(listID, req.TaskId, &tasks.Task{Status: taskStatusCompleted}).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to complete task")
+	}
+
+	return &pb.CompleteTaskResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Task completed successfully."},
+		Task:   taskToProto(listID, completed),
+	}, nil
+}
+
+func (s *tasksServer) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.UpdateTaskResponse, error) {
+	if req.TaskId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A task ID is required.")
+	}
+
+	mask := req.UpdateMask
+	if len(mask) == 0 {
+		if req.Title != "" {
+			mask = append(mask, "title")
+		}
+		if req.Notes != "" {
+			mask = append(mask, "notes")
+		}
+		if req.Due != "" {
+			mask = append(mask, "due")
+		}
+	}
+	if len(mask) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Nothing to update.")
+	}
+
+	// A field in the mask with an empty value clears it.
+	patch := &tasks.Task{}
+	for _, field := range mask {
+		switch field {
+		case "title":
+			if req.Title == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "A task's title cannot be cleared.")
+			}
+			patch.Title = req.Title
+		case "notes":
+			patch.Notes = req.Notes
+			patch.ForceSendFields = append(patch.ForceSendFields, "Notes")
+		case "due":
+			if req.Due == "" {
+				patch.NullFields = append(patch.NullFields, "Due")
+				continue
+			}
+			due, err := parseTaskDue(req.Due)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+			patch.Due = due.Format(time.RFC3339)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown update_mask field %q.", field)
+		}
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	listID := taskListID(req.TaskListId)
+	updated, err := srv.Tasks.Patch(listID, req.TaskId, patch).Context(ctx).Do()
+	if err != nil {
+		return nil, googleAPIError(err, "Unable to update task")
+	}
+
+	return &pb.UpdateTaskResponse{
+		Common:      &pb.CommonResponse{Status: "OK", Message: "Task updated successfully."},
+		UpdatedTask: taskToProto(listID, updated),
+	}, nil
+}
+
+func (s *tasksServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error) {
+	if req.TaskId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A task ID is required.")
+	}
+
+	tok, err := getTokenFromRequest(ctx, req.Common)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := googleService(ctx, req.Common.GetUserId(), tok, tasks.NewService)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unable to retrieve Tasks client: %v", err)
+	}
+
+	if err := srv.Tasks.Delete(taskListID(req.TaskListId), req.TaskId).Context(ctx).Do(); err != nil {
+		return nil, googleAPIError(err, "Unable to delete task")
+	}
+
+	return &pb.DeleteTaskResponse{
+		Common: &pb.CommonResponse{Status: "OK", Message: "Task deleted successfully."},
+	}, nil
+}
@@ -0,0 +1,351 @@
+// mcp_services/token_store.go
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// File where the encrypted OAuth tokens of authorized users are persisted
+	tokenStoreFile = "tokens.enc.json"
+
+	// Base64-encoded AES-256 key used to encrypt stored tokens
+	tokenKeyEnv = "TOKEN_ENCRYPTION_KEY"
+	// Comma-separated base64 keys that tokens may still be encrypted with
+	tokenOldKeysEnv = "TOKEN_ENCRYPTION_OLD_KEYS"
+	// File holding the current key on its first line and old keys on the
+	// following ones. Takes precedence over the two variables above.
+	tokenKeyFileEnv = "TOKEN_ENCRYPTION_KEY_FILE"
+
+	tokenKeySize = 32
+)
+
+var errTokenNotFound = errors.New("no OAuth tokens stored for user")
+
+// TokenStore keeps the Google OAuth tokens of every authorized user, keyed
+// by the user ID clients send in CommonRequest.user_id, so refresh tokens
+// never have to leave the server.
+type TokenStore interface {
+	// Get returns the tokens of userID, or errTokenNotFound.
+	Get(userID string) (*oauth2.Token, error)
+	// Put stores the tokens of userID. Google only returns a refresh token
+	// the first time a user consents, so a stored refresh token is kept when
+	// tok has none. The scopes and account email Google reports in the token
+	// response replace those stored for the user; without them they are kept.
+	Put(userID string, tok *oauth2.Token) error
+	// Grant returns everything stored for userID, or errTokenNotFound.
+	Grant(userID string) (*userGrant, error)
+	// Delete removes the tokens of userID, if any.
+	Delete(userID string) error
+}
+
+// userGrant is what the store keeps for a user.
+type userGrant struct {
+	Token *oauth2.Token `json:"token"`
+	// Scopes the user granted. nil for tokens stored before scopes were
+	// tracked, which were granted every scope the server used to request.
+	Scopes []string `json:"scopes,omitempty"`
+	// Address of the Google account, from the ID token
+	Email string `json:"email,omitempty"`
+}
+
+// tokenKey is an AES-256-GCM key. Its ID, derived from the key, is saved
+// with every sealed token so the right key can be picked to open it.
+type tokenKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+func newTokenKey(encoded string) (*tokenKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(raw) != tokenKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", tokenKeySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &tokenKey{id: hex.EncodeToString(sum[:8]), aead: aead}, nil
+}
+
+// loadTokenKeys returns the key new tokens are encrypted with and the old
+// keys still accepted for decryption, from TOKEN_ENCRYPTION_KEY_FILE or
+// else TOKEN_ENCRYPTION_KEY and TOKEN_ENCRYPTION_OLD_KEYS.
+func loadTokenKeys() (*tokenKey, []*tokenKey, error) {
+	var encoded []string
+	if path := os.Getenv(tokenKeyFileEnv); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				encoded = append(encoded, line)
+			}
+		}
+		if len(encoded) == 0 {
+			return nil, nil, fmt.Errorf("%s contains no key", path)
+		}
+	} else {
+		key := os.Getenv(tokenKeyEnv)
+		if key == "" {
+			return nil, nil, fmt.Errorf("set %s or %s to a base64-encoded %d-byte key (e.g. the output of 'openssl rand -base64 %d')", tokenKeyEnv, tokenKeyFileEnv, tokenKeySize, tokenKeySize)
+		}
+		encoded = append(encoded, key)
+		for _, old := range strings.Split(os.Getenv(tokenOldKeysEnv), ",") {
+			if old = strings.TrimSpace(old); old != "" {
+				encoded = append(encoded, old)
+			}
+		}
+	}
+
+	keys := make([]*tokenKey, 0, len(encoded))
+	for i, e := range encoded {
+		k, err := newTokenKey(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid token encryption key #%d: %w", i+1, err)
+		}
+		keys = append(keys, k)
+	}
+	return keys[0], keys[1:], nil
+}
+
+// sealedToken is the on-disk form of a user's tokens.
+type sealedToken struct {
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptedTokenStore is a TokenStore persisted to a JSON file in which each
+// user's tokens are encrypted with AES-GCM. The user ID is authenticated
+// along with the tokens, so sealed tokens cannot be moved to another user.
+// Tokens encrypted with an old key are re-encrypted with the current key the
+// next time they are read.
+type encryptedTokenStore struct {
+	mu      sync.Mutex
+	path    string
+	key     *tokenKey
+	oldKeys map[string]*tokenKey
+	sealed  map[string]*sealedToken
+}
+
+// newEncryptedTokenStore loads the store from path, creating it if missing.
+func newEncryptedTokenStore(path string, key *tokenKey, oldKeys []*tokenKey) (*encryptedTokenStore, error) {
+	s := &encryptedTokenStore{
+		path:    path,
+		key:     key,
+		oldKeys: make(map[string]*tokenKey),
+		sealed:  make(map[string]*sealedToken),
+	}
+	for _, k := range oldKeys {
+		s.oldKeys[k.id] = k
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(b, &s.sealed); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	stale := 0
+	for _, st := range s.sealed {
+		if st.KeyID != key.id {
+			stale++
+		}
+	}
+	if stale > 0 {
+		log.Printf("Tokens of %d user(s) in %s are encrypted with an old key; they are re-encrypted as they are used.", stale, path)
+	}
+	return s, nil
+}
+
+func (s *encryptedTokenStore) seal(userID string, grant *userGrant) (*sealedToken, error) {
+	plaintext, err := json.Marshal(grant)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal token: %w", err)
+	}
+	nonce := make([]byte, s.key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &sealedToken{
+		KeyID:      s.key.id,
+		Nonce:      nonce,
+		Ciphertext: s.key.aead.Seal(nil, nonce, plaintext, []byte(userID)),
+	}, nil
+}
+
+func (s *encryptedTokenStore) open(userID string, st *sealedToken) (*userGrant, error) {
+	key := s.key
+	if st.KeyID != key.id {
+		var ok bool
+		if key, ok = s.oldKeys[st.KeyID]; !ok {
+			return nil, fmt.Errorf("tokens of user %s are encrypted with unknown key %s", userID, st.KeyID)
+		}
+	}
+	plaintext, err := key.aead.Open(nil, st.Nonce, st.Ciphertext, []byte(userID))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt tokens of user %s: %w", userID, err)
+	}
+	var grant userGrant
+	if err := json.Unmarshal(plaintext, &grant); err != nil {
+		return nil, fmt.Errorf("unable to parse tokens of user %s: %w", userID, err)
+	}
+	if grant.Token == nil {
+		// Sealed before scopes were tracked, when only the token was stored.
+		grant.Token = &oauth2.Token{}
+		if err := json.Unmarshal(plaintext, grant.Token); err != nil {
+			return nil, fmt.Errorf("unable to parse tokens of user %s: %w", userID, err)
+		}
+	}
+	return &grant, nil
+}
+
+// save writes the store to disk. Callers must hold s.mu.
+func (s *encryptedTokenStore) save() error {
+	b, err := json.MarshalIndent(s.sealed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal tokens: %w", err)
+	}
+	// Write to a temporary file and rename it so a crash never leaves a truncated store.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("unable to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("unable to replace %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// read opens the grant of userID, re-encrypting it if it was sealed with an
+// old key. Callers must hold s.mu.
+func (s *encryptedTokenStore) read(userID string) (*userGrant, error) {
+	st, ok := s.sealed[userID]
+	if !ok {
+		return nil, errTokenNotFound
+	}
+	grant, err := s.open(userID, st)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.KeyID != s.key.id {
+		resealed, err := s.seal(userID, grant)
+		if err == nil {
+			s.sealed[userID] = resealed
+			if err = s.save(); err != nil {
+				s.sealed[userID] = st
+			}
+		}
+		if err != nil {
+			// The tokens are still usable; rotation is retried on the next read.
+			log.Printf("Unable to re-encrypt tokens of user %s with the current key: %v", userID, err)
+		}
+	}
+	return grant, nil
+}
+
+// Get implements TokenStore.
+func (s *encryptedTokenStore) Get(userID string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grant, err := s.read(userID)
+	if err != nil {
+		return nil, err
+	}
+	return grant.Token, nil
+}
+
+// Grant implements TokenStore.
+func (s *encryptedTokenStore) Grant(userID string) (*userGrant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(userID)
+}
+
+// Delete implements TokenStore.
+func (s *encryptedTokenStore) Delete(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.sealed[userID]
+	if !existed {
+		return nil
+	}
+	delete(s.sealed, userID)
+	if err := s.save(); err != nil {
+		s.sealed[userID] = old
+		return err
+	}
+	return nil
+}
+
+// Put implements TokenStore.
+func (s *encryptedTokenStore) Put(userID string, tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grant := &userGrant{Token: tok, Email: idTokenEmail(tok)}
+	if scope, ok := tok.Extra("scope").(string); ok && scope != "" {
+		grant.Scopes = strings.Fields(scope)
+	}
+	old, existed := s.sealed[userID]
+	if existed && (tok.RefreshToken == "" || grant.Scopes == nil || grant.Email == "") {
+		if prev, err := s.open(userID, old); err == nil {
+			if tok.RefreshToken == "" {
+				c := *tok
+				c.RefreshToken = prev.Token.RefreshToken
+				grant.Token = &c
+			}
+			if grant.Scopes == nil {
+				grant.Scopes = prev.Scopes
+			}
+			if grant.Email == "" {
+				grant.Email = prev.Email
+			}
+		}
+	}
+	st, err := s.seal(userID, grant)
+	if err != nil {
+		return err
+	}
+	s.sealed[userID] = st
+	if err := s.save(); err != nil {
+		if existed {
+			s.sealed[userID] = old
+		} else {
+			delete(s.sealed, userID)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// mcp_services/token_store_test.go
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func testTokenKey(t *testing.T) *tokenKey {
+	t.Helper()
+	raw := make([]byte, tokenKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	k, err := newTokenKey(base64.StdEncoding.EncodeToString(raw))
+	if err != nil {
+		t.Fatalf("newTokenKey: %v", err)
+	}
+	return k
+}
+
+func openTestTokenStore(t *testing.T, path string, key *tokenKey, oldKeys ...*tokenKey) *encryptedTokenStore {
+	t.Helper()
+	s, err := newEncryptedTokenStore(path, key, oldKeys)
+	if err != nil {
+		t.Fatalf("newEncryptedTokenStore: %v", err)
+	}
+	return s
+}
+
+func testToken() *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  "ya29.secret-access-token",
+		RefreshToken: "1//secret-refresh-token",
+		TokenType:    "Bearer",
+		Expiry:       time.Unix(1700000000, 0),
+	}
+}
+
+func TestEncryptedTokenStoreKeepsNoPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokenStoreFile)
+	key := testTokenKey(t)
+	if err := openTestTokenStore(t, path, key).Put("5491122334455", testToken()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-access-token", "secret-refresh-token", "Bearer"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("%s contains %q:\n%s", tokenStoreFile, secret, b)
+		}
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("%s has mode %v, want 0600", tokenStoreFile, info.Mode().Perm())
+	}
+
+	tok, err := openTestTokenStore(t, path, key).Get("5491122334455")
+	if err != nil {
+		t.Fatalf("Get after reopening: %v", err)
+	}
+	if tok.AccessToken != "ya29.secret-access-token" || tok.RefreshToken != "1//secret-refresh-token" || !tok.Expiry.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Token = %+v, want the stored one", tok)
+	}
+}
+
+func TestEncryptedTokenStoreKeepsRefreshToken(t *testing.T) {
+	s := openTestTokenStore(t, filepath.Join(t.TempDir(), tokenStoreFile), testTokenKey(t))
+	if err := s.Put("u1", testToken()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put("u1", &oauth2.Token{AccessToken: "ya29.refreshed"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	tok, err := s.Get("u1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if tok.AccessToken != "ya29.refreshed" || tok.RefreshToken != "1//secret-refresh-token" {
+		t.Errorf("Token = %+v, want the new access token and the old refresh token", tok)
+	}
+}
+
+func TestEncryptedTokenStoreKeyRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokenStoreFile)
+	oldKey, newKey := testTokenKey(t), testTokenKey(t)
+	if err := openTestTokenStore(t, path, oldKey).Put("u1", testToken()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	tok, err := openTestTokenStore(t, path, newKey, oldKey).Get("u1")
+	if err != nil {
+		t.Fatalf("Get with the old key retired to oldKeys: %v", err)
+	}
+	if tok.RefreshToken != "1//secret-refresh-token" {
+		t.Errorf("RefreshToken = %q, want the stored one", tok.RefreshToken)
+	}
+
+	// Reading re-encrypted the tokens, so the old key is no longer needed.
+	var sealed map[string]*sealedToken
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &sealed); err != nil {
+		t.Fatal(err)
+	}
+	if sealed["u1"].KeyID != newKey.id {
+		t.Errorf("KeyID = %s, want the new key %s", sealed["u1"].KeyID, newKey.id)
+	}
+	if _, err := openTestTokenStore(t, path, newKey).Get("u1"); err != nil {
+		t.Errorf("Get with only the new key: %v", err)
+	}
+}
+
+func TestEncryptedTokenStoreWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokenStoreFile)
+	if err := openTestTokenStore(t, path, testTokenKey(t)).Put("u1", testToken()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	_, err := openTestTokenStore(t, path, testTokenKey(t)).Get("u1")
+	if err == nil || errors.Is(err, errTokenNotFound) {
+		t.Errorf("Get with a wrong key = %v, want a decryption error", err)
+	}
+}
+
+func TestEncryptedTokenStoreBindsTokensToUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokenStoreFile)
+	key := testTokenKey(t)
+	s := openTestTokenStore(t, path, key)
+	if err := s.Put("victim", testToken()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Copying the sealed tokens to another user ID must not make them usable.
+	s.sealed["attacker"] = s.sealed["victim"]
+	if _, err := s.Get("attacker"); err == nil {
+		t.Error("Get of tokens moved to another user succeeded")
+	}
+}
+
+func TestEncryptedTokenStoreKeepsScopesAndEmail(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"email":"ana@example.com"}`))
+	consented := testToken().WithExtra(map[string]interface{}{
+		"scope":    "openid https://www.googleapis.com/auth/tasks",
+		"id_token": "header." + claims + ".signature",
+	})
+
+	s := openTestTokenStore(t, filepath.Join(t.TempDir(), tokenStoreFile), testTokenKey(t))
+	if err := s.Put("u1", consented); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// Refreshed tokens carry neither scopes nor an ID token.
+	if err := s.Put("u1", &oauth2.Token{AccessToken: "ya29.refreshed"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	grant, err := s.Grant("u1")
+	if err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if grant.Email != "ana@example.com" {
+		t.Errorf("Email = %q, want ana@example.com", grant.Email)
+	}
+	if want := []string{"openid", "https://www.googleapis.com/auth/tasks"}; !reflect.DeepEqual(grant.Scopes, want) {
+		t.Errorf("Scopes = %q, want %q", grant.Scopes, want)
+	}
+
+	if err := s.Delete("u1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Grant("u1"); !errors.Is(err, errTokenNotFound) {
+		t.Errorf("Grant after Delete = %v, want errTokenNotFound", err)
+	}
+}
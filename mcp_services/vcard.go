@@ -0,0 +1,477 @@
+// mcp_services/vcard.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// Minimal vCard 3.0 (RFC 2426) and 4.0 (RFC 6350) codec covering the fields
+// of pb.Person. Unknown properties are ignored on parse.
+
+const (
+	vCardVersion3 = "3.0"
+	vCardVersion4 = "4.0"
+	// Lines longer than this many octets are folded on output
+	vCardMaxLineOctets = 75
+)
+
+// vCardProperty is a single unfolded content line, e.g.
+// "item1.TEL;TYPE=CELL,VOICE:+54 9 11 1234-5678".
+type vCardProperty struct {
+	Name   string              // Upper-cased, without group prefix
+	Params map[string][]string // Upper-cased parameter names to values
+	Value  string              // Raw (still escaped) value
+}
+
+// types returns the lower-cased TYPE parameter values, including vCard 2.1
+// style bare parameters such as "TEL;CELL:".
+func (p *vCardProperty) types() []string {
+	var types []string
+	for _, value := range p.Params["TYPE"] {
+		// vCard 4.0 allows quoted lists, e.g. TYPE="work,voice"
+		for _, t := range strings.Split(value, ",") {
+			types = append(types, strings.ToLower(strings.TrimSpace(t)))
+		}
+	}
+	return types
+}
+
+// unfoldVCardLines splits data into logical lines, joining continuation
+// lines that start with a space or tab.
+func unfoldVCardLines(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseVCardProperty parses an unfolded content line.
+func parseVCardProperty(line string) (*vCardProperty, error) {
+	// The value starts at the first colon outside a quoted parameter value.
+	colon := -1
+	inQuotes := false
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return nil, fmt.Errorf("invalid vCard line %q", line)
+	}
+
+	prop := &vCardProperty{Params: make(map[string][]string), Value: line[colon+1:]}
+	parts := splitUnquoted(line[:colon], ';')
+	name := parts[0]
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:] // Drop the group, e.g. "item1."
+	}
+	prop.Name = strings.ToUpper(strings.TrimSpace(name))
+
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			// vCard 2.1 bare parameter, e.g. "WORK"
+			key, value = "TYPE", param
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		for _, v := range splitUnquoted(value, ',') {
+			prop.Params[key] = append(prop.Params[key], strings.Trim(v, `"`))
+		}
+	}
+	return prop, nil
+}
+
+// splitUnquoted splits s on sep, ignoring separators inside double quotes.
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	var b strings.Builder
+	inQuotes := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			b.WriteRune(r)
+		case r == sep && !inQuotes:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(parts, b.String())
+}
+
+// splitVCardComponents splits a structured value (N, ADR, ORG) on unescaped
+// semicolons and unescapes each component.
+func splitVCardComponents(value string) []string {
+	var parts []string
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			parts = append(parts, unescapeVCardText(b.String()))
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(parts, unescapeVCardText(b.String()))
+}
+
+func unescapeVCardText(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var vCardTextEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+
+func escapeVCardText(s string) string {
+	return vCardTextEscaper.Replace(s)
+}
+
+// vCardContactType picks the pb.Person type ("home", "work", "mobile",
+// "other") from TYPE parameters.
+func vCardContactType(types []string) string {
+	for _, t := range types {
+		switch t {
+		case "cell", "mobile":
+			return "mobile"
+		case "home", "work", "other":
+			return t
+		}
+	}
+	return ""
+}
+
+// parseVCardBirthday converts BDAY values such as "19900115", "1990-01-15",
+// "--0115" or "--01-15" to the "YYYY-MM-DD" / "--MM-DD" form of pb.Person.
+func parseVCardBirthday(value string) string {
+	value = strings.TrimSpace(value)
+	if t := strings.Index(value, "T"); t >= 0 {
+		value = value[:t] // Drop a time part
+	}
+	if strings.HasPrefix(value, "--") {
+		md := strings.ReplaceAll(value[2:], "-", "")
+		if len(md) == 4 {
+			return "--" + md[:2] + "-" + md[2:]
+		}
+		return ""
+	}
+	ymd := strings.ReplaceAll(value, "-", "")
+	if len(ymd) == 8 {
+		return ymd[:4] + "-" + ymd[4:6] + "-" + ymd[6:]
+	}
+	return ""
+}
+
+// ParseVCards parses every card in data, which may hold several
+// BEGIN:VCARD/END:VCARD blocks as exported by phones.
+func ParseVCards(data string) ([]*pb.Person, error) {
+	var people []*pb.Person
+	var current *pb.Person
+	var structuredName string
+
+	for _, line := range unfoldVCardLines(data) {
+		prop, err := parseVCardProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			if strings.EqualFold(prop.Value, "VCARD") {
+				if current != nil {
+					return nil, fmt.Errorf("nested BEGIN:VCARD")
+				}
+				current = &pb.Person{}
+				structuredName = ""
+			}
+			continue
+		case "END":
+			if strings.EqualFold(prop.Value, "VCARD") {
+				if current == nil {
+					return nil, fmt.Errorf("END:VCARD without BEGIN:VCARD")
+				}
+				if current.DisplayName == "" {
+					current.DisplayName = structuredName
+				}
+				if len(current.Emails) > 0 {
+					current.Email = current.Emails[0].Value
+				}
+				if len(current.Phones) > 0 {
+					current.PhoneNumber = current.Phones[0].Value
+				}
+				people = append(people, current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			continue // Content outside a card
+		}
+
+		switch prop.Name {
+		case "FN":
+			current.DisplayName = unescapeVCardText(prop.Value)
+		case "N":
+			// family;given;additional;prefix;suffix
+			c := splitVCardComponents(prop.Value)
+			for len(c) < 5 {
+				c = append(c, "")
+			}
+			structuredName = strings.Join(strings.Fields(strings.Join([]string{c[3], c[1], c[2], c[0], c[4]}, " ")), " ")
+		case "EMAIL":
+			current.Emails = append(current.Emails, &pb.ContactEmail{
+				Value: strings.TrimPrefix(unescapeVCardText(prop.Value), "mailto:"),
+				Type:  vCardContactType(prop.types()),
+			})
+		case "TEL":
+			current.Phones = append(current.Phones, &pb.ContactPhone{
+				Value: strings.TrimPrefix(unescapeVCardText(prop.Value), "tel:"),
+				Type:  vCardContactType(prop.types()),
+			})
+		case "ORG":
+			c := splitVCardComponents(prop.Value)
+			org := &pb.Organization{Name: c[0]}
+			if len(c) > 1 {
+				org.Department = c[1]
+			}
+			if len(current.Organizations) == 0 {
+				current.Organizations = append(current.Organizations, org)
+			} else {
+				current.Organizations[0].Name = org.Name
+				current.Organizations[0].Department = org.Department
+			}
+		case "TITLE":
+			if len(current.Organizations) == 0 {
+				current.Organizations = append(current.Organizations, &pb.Organization{})
+			}
+			current.Organizations[0].Title = unescapeVCardText(prop.Value)
+		case "BDAY":
+			current.Birthday = parseVCardBirthday(prop.Value)
+		case "ADR":
+			// pobox;extended;street;locality;region;postal code;country
+			c := splitVCardComponents(prop.Value)
+			for len(c) < 7 {
+				c = append(c, "")
+			}
+			addr := &pb.PostalAddress{
+				Street:     strings.TrimSpace(c[1] + " " + c[2]),
+				City:       c[3],
+				Region:     c[4],
+				PostalCode: c[5],
+				Country:    c[6],
+				Type:       vCardContactType(prop.types()),
+			}
+			if labels := prop.Params["LABEL"]; len(labels) > 0 {
+				addr.Formatted = unescapeVCardText(strings.Join(labels, ","))
+				// Undo FormatVCard's copy of an unstructured address into the street.
+				if addr.Street == addr.Formatted && addr.City == "" && addr.Region == "" && addr.PostalCode == "" && addr.Country == "" {
+					addr.Street = ""
+				}
+			}
+			current.Addresses = append(current.Addresses, addr)
+		case "NOTE":
+			current.Notes = unescapeVCardText(prop.Value)
+		case "PHOTO":
+			// Only keep photo links; inline base64 images are dropped.
+			v := prop.Value
+			if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+				current.PhotoUrl = v
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("missing END:VCARD")
+	}
+	return people, nil
+}
+
+// vCardWriter accumulates folded content lines.
+type vCardWriter struct {
+	b strings.Builder
+}
+
+// line writes a content line, folding it at vCardMaxLineOctets without
+// splitting UTF-8 sequences.
+func (w *vCardWriter) line(s string) {
+	limit := vCardMaxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.b.WriteString(s[:cut])
+		w.b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = vCardMaxLineOctets - 1 // Account for the leading space
+	}
+	w.b.WriteString(s)
+	w.b.WriteString("\r\n")
+}
+
+// vCardTypeParam renders a TYPE parameter for a pb.Person type in the
+// conventions of the given vCard version.
+func vCardTypeParam(kind, version string, extra ...string) string {
+	var types []string
+	types = append(types, extra...)
+	switch kind {
+	case "":
+	case "mobile":
+		types = append(types, "cell")
+	default:
+		types = append(types, kind)
+	}
+	if len(types) == 0 {
+		return ""
+	}
+	value := strings.Join(types, ",")
+	if version == vCardVersion3 {
+		value = strings.ToUpper(value)
+	}
+	return ";TYPE=" + value
+}
+
+// FormatVCard serializes a person as a single vCard of the given version
+// ("3.0" or "4.0").
+func FormatVCard(p *pb.Person, version string) (string, error) {
+	if version == "" {
+		version = vCardVersion3
+	}
+	if version != vCardVersion3 && version != vCardVersion4 {
+		return "", fmt.Errorf("unsupported vCard version %q", version)
+	}
+
+	w := &vCardWriter{}
+	w.line("BEGIN:VCARD")
+	w.line("VERSION:" + version)
+	w.line("FN:" + escapeVCardText(p.DisplayName))
+
+	// N is mandatory in 3.0; split the display name into given and family names.
+	name := strings.TrimSpace(p.DisplayName)
+	given, family := name, ""
+	if i := strings.LastIndex(name, " "); i > 0 {
+		given, family = strings.TrimSpace(name[:i]), name[i+1:]
+	}
+	w.line(fmt.Sprintf("N:%s;%s;;;", escapeVCardText(family), escapeVCardText(given)))
+
+	emails := p.Emails
+	if len(emails) == 0 && p.Email != "" {
+		emails = []*pb.ContactEmail{{Value: p.Email}}
+	}
+	for _, e := range emails {
+		if version == vCardVersion3 {
+			w.line("EMAIL" + vCardTypeParam(e.Type, version, "internet") + ":" + escapeVCardText(e.Value))
+		} else {
+			w.line("EMAIL" + vCardTypeParam(e.Type, version) + ":" + escapeVCardText(e.Value))
+		}
+	}
+
+	phones := p.Phones
+	if len(phones) == 0 && p.PhoneNumber != "" {
+		phones = []*pb.ContactPhone{{Value: p.PhoneNumber}}
+	}
+	for _, ph := range phones {
+		// Numbers are written as text in both versions, since the 4.0 tel: URI
+		// form cannot hold the spaces people type.
+		w.line("TEL" + vCardTypeParam(ph.Type, version) + ":" + escapeVCardText(ph.Value))
+	}
+
+	if len(p.Organizations) > 0 {
+		o := p.Organizations[0]
+		if o.Name != "" || o.Department != "" {
+			org := escapeVCardText(o.Name)
+			if o.Department != "" {
+				org += ";" + escapeVCardText(o.Department)
+			}
+			w.line("ORG:" + org)
+		}
+		if o.Title != "" {
+			w.line("TITLE:" + escapeVCardText(o.Title))
+		}
+	}
+
+	if p.Birthday != "" {
+		bday := p.Birthday
+		if version == vCardVersion4 && strings.HasPrefix(bday, "--") {
+			bday = "--" + strings.ReplaceAll(bday[2:], "-", "") // RFC 6350 form: --MMDD
+		}
+		w.line("BDAY:" + bday)
+	}
+
+	for _, a := range p.Addresses {
+		// LABEL is a 4.0 parameter, but 3.0 readers ignore unknown parameters.
+		label := ""
+		if a.Formatted != "" {
+			label = `;LABEL="` + strings.ReplaceAll(strings.ReplaceAll(a.Formatted, `"`, "'"), "\n", `\n`) + `"`
+		}
+		street := a.Street
+		if street == "" && a.City == "" && a.Region == "" && a.PostalCode == "" && a.Country == "" {
+			street = a.Formatted // Unstructured addresses go in the street component
+		}
+		w.line("ADR" + vCardTypeParam(a.Type, version) + label + ":;;" + strings.Join([]string{
+			escapeVCardText(street),
+			escapeVCardText(a.City),
+			escapeVCardText(a.Region),
+			escapeVCardText(a.PostalCode),
+			escapeVCardText(a.Country),
+		}, ";"))
+	}
+
+	if p.Notes != "" {
+		w.line("NOTE:" + escapeVCardText(p.Notes))
+	}
+	if p.PhotoUrl != "" {
+		if version == vCardVersion3 {
+			w.line("PHOTO;VALUE=uri:" + p.PhotoUrl)
+		} else {
+			w.line("PHOTO:" + p.PhotoUrl)
+		}
+	}
+
+	w.line("END:VCARD")
+	return w.b.String(), nil
+}
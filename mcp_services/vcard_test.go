@@ -0,0 +1,211 @@
+// mcp_services/vcard_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/pmartinizquierdob/mcp-google-services/pb"
+)
+
+// roundTripPerson has every field FormatVCard writes, with text needing
+// escapes.
+func roundTripPerson() *pb.Person {
+	return &pb.Person{
+		DisplayName: "José María Pérez",
+		Email:       "jose@example.com",
+		Emails: []*pb.ContactEmail{
+			{Value: "jose@example.com", Type: "work"},
+			{Value: "jmp@example.org", Type: "home"},
+		},
+		PhoneNumber: "+54 9 11 1234-5678",
+		Phones: []*pb.ContactPhone{
+			{Value: "+54 9 11 1234-5678", Type: "mobile"},
+			{Value: "+54 11 4321-8765", Type: "work"},
+		},
+		Organizations: []*pb.Organization{
+			{Name: "Acme, S.A.", Department: "I+D; Backend", Title: "Líder técnico"},
+		},
+		Birthday: "1990-01-15",
+		Addresses: []*pb.PostalAddress{
+			{
+				Street:     "Av. Corrientes 1234",
+				City:       "Buenos Aires",
+				Region:     "CABA",
+				PostalCode: "C1043",
+				Country:    "Argentina",
+				Type:       "work",
+				Formatted:  "Av. Corrientes 1234\nC1043 Buenos Aires, Argentina",
+			},
+			{Formatted: "Casa de la abuela; al lado del kiosco", Type: "home"},
+		},
+		Notes:    "Línea 1\nLínea 2, con coma; punto y coma y barra \\",
+		PhotoUrl: "https://example.com/photos/jose.jpg",
+	}
+}
+
+func TestVCardRoundTrip(t *testing.T) {
+	for _, version := range []string{vCardVersion3, vCardVersion4} {
+		for _, birthday := range []string{"1990-01-15", "--01-15"} {
+			want := roundTripPerson()
+			want.Birthday = birthday
+
+			card, err := FormatVCard(want, version)
+			if err != nil {
+				t.Fatalf("FormatVCard(%s): %v", version, err)
+			}
+			if !strings.Contains(card, "VERSION:"+version+"\r\n") {
+				t.Errorf("vCard %s lacks its VERSION line:\n%s", version, card)
+			}
+
+			got, err := ParseVCards(card)
+			if err != nil {
+				t.Fatalf("ParseVCards(%s): %v\n%s", version, err, card)
+			}
+			if len(got) != 1 {
+				t.Fatalf("ParseVCards(%s) returned %d cards, want 1", version, len(got))
+			}
+			if !proto.Equal(got[0], want) {
+				t.Errorf("vCard %s round trip with birthday %s:\n got %v\nwant %v\ncard:\n%s", version, birthday, got[0], want, card)
+			}
+		}
+	}
+}
+
+func TestFormatVCardFoldsLongLines(t *testing.T) {
+	// Multi-byte characters all along the note land on every fold boundary.
+	note := strings.Repeat("ñandú ", 60)
+	card, err := FormatVCard(&pb.Person{DisplayName: "Largo", Notes: note}, vCardVersion4)
+	if err != nil {
+		t.Fatalf("FormatVCard: %v", err)
+	}
+
+	folded := false
+	for _, line := range strings.Split(strings.TrimSuffix(card, "\r\n"), "\r\n") {
+		if len(line) > vCardMaxLineOctets {
+			t.Errorf("line of %d octets exceeds %d: %q", len(line), vCardMaxLineOctets, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("fold split a UTF-8 sequence: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			folded = true
+		}
+	}
+	if !folded {
+		t.Fatalf("long note was not folded:\n%s", card)
+	}
+
+	got, err := ParseVCards(card)
+	if err != nil {
+		t.Fatalf("ParseVCards: %v", err)
+	}
+	if got[0].Notes != note {
+		t.Errorf("unfolded note = %q, want %q", got[0].Notes, note)
+	}
+}
+
+func TestVCardTextEscaping(t *testing.T) {
+	tests := []struct {
+		text    string
+		escaped string
+	}{
+		{"plain", "plain"},
+		{"a,b", `a\,b`},
+		{"a;b", `a\;b`},
+		{`a\b`, `a\\b`},
+		{"a\nb", `a\nb`},
+		{`\n`, `\\n`},
+	}
+	for _, tt := range tests {
+		if got := escapeVCardText(tt.text); got != tt.escaped {
+			t.Errorf("escapeVCardText(%q) = %q, want %q", tt.text, got, tt.escaped)
+		}
+		if got := unescapeVCardText(tt.escaped); got != tt.text {
+			t.Errorf("unescapeVCardText(%q) = %q, want %q", tt.escaped, got, tt.text)
+		}
+	}
+
+	// Readers must accept \N too, and keep escaped semicolons inside a component.
+	if got := unescapeVCardText(`a\Nb`); got != "a\nb" {
+		t.Errorf(`unescapeVCardText("a\\Nb") = %q`, got)
+	}
+	if got, want := splitVCardComponents(`Pérez\; Gómez;Ana;;;`), []string{"Pérez; Gómez", "Ana", "", "", ""}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitVCardComponents = %q, want %q", got, want)
+	}
+}
+
+func TestParseVCardsMultipleCards(t *testing.T) {
+	// A phone export: 2.1-style bare types, grouped properties, a line folded
+	// with a tab, LF line endings, and a 4.0 card after a 3.0 one.
+	data := "BEGIN:VCARD\n" +
+		"VERSION:3.0\n" +
+		"N:Gómez;Ana;;;\n" +
+		"item1.TEL;CELL:+54 9 11 5555-0000\n" +
+		"item1.X-ABLabel:Celular\n" +
+		"EMAIL;TYPE=INTERNET,HOME:ana@example.com\n" +
+		"NOTE:Conocida del\n" +
+		"\t club\n" +
+		"END:VCARD\n" +
+		"BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"FN:Bruno Díaz\r\n" +
+		"TEL;TYPE=\"work,voice\":tel:+54-11-4000-0000\r\n" +
+		"EMAIL:mailto:bruno@example.com\r\n" +
+		"BDAY:--0229\r\n" +
+		"PHOTO:data:image/jpeg;base64,AAAA\r\n" +
+		"END:VCARD\r\n"
+
+	got, err := ParseVCards(data)
+	if err != nil {
+		t.Fatalf("ParseVCards: %v", err)
+	}
+	want := []*pb.Person{
+		{
+			DisplayName: "Ana Gómez",
+			Email:       "ana@example.com",
+			Emails:      []*pb.ContactEmail{{Value: "ana@example.com", Type: "home"}},
+			PhoneNumber: "+54 9 11 5555-0000",
+			Phones:      []*pb.ContactPhone{{Value: "+54 9 11 5555-0000", Type: "mobile"}},
+			Notes:       "Conocida del club",
+		},
+		{
+			DisplayName: "Bruno Díaz",
+			Email:       "bruno@example.com",
+			Emails:      []*pb.ContactEmail{{Value: "bruno@example.com"}},
+			PhoneNumber: "+54-11-4000-0000",
+			Phones:      []*pb.ContactPhone{{Value: "+54-11-4000-0000", Type: "work"}},
+			Birthday:    "--02-29",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseVCards returned %d cards, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("card %d:\n got %v\nwant %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseVCardsErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"nested":      "BEGIN:VCARD\nBEGIN:VCARD\nEND:VCARD\nEND:VCARD\n",
+		"missing end": "BEGIN:VCARD\nFN:Ana\n",
+		"stray end":   "END:VCARD\n",
+		"no colon":    "BEGIN:VCARD\nFN Ana\nEND:VCARD\n",
+	} {
+		if _, err := ParseVCards(data); err == nil {
+			t.Errorf("ParseVCards(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestFormatVCardRejectsUnknownVersion(t *testing.T) {
+	if _, err := FormatVCard(&pb.Person{DisplayName: "Ana"}, "2.1"); err == nil {
+		t.Error("FormatVCard accepted version 2.1")
+	}
+}